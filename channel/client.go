@@ -0,0 +1,203 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer"
+	signerV4 "github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer/v4"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// Default service for v4 signature
+var service = "kinesisvideo"
+
+// Max error response body read from control plane
+const maxErrorBody = 64 * 1024
+
+// Kinesis Video control plane client, it calls signaling channel endpoints too
+type Client struct {
+	region              string                // AWS Region
+	endpoint            string                // Control plane HTTPS URL
+	signer              signer.RequestSignerI // V4 AWS Signer
+	httpClient          *http.Client          // Client for control plane requests
+	credentialsValue    *credentials.Value    // AWS Credentials, nil for AWS machine credentials provider
+	credentialsProvider credentials.Provider  // AWS Credentials provider, it takes precedence over credentials value
+}
+
+// Optional parameters
+
+// Use own control plane endpoint, like a local stand-in
+func WithEndpoint(endpoint string) func(*Client) {
+	return func(c *Client) {
+		c.endpoint = endpoint
+	}
+}
+
+// Use own signer
+func WithSigner(s signer.RequestSignerI) func(*Client) {
+	return func(c *Client) {
+		c.signer = s
+	}
+}
+
+// Use own http client, for timeouts or proxies
+func WithHTTPClient(httpClient *http.Client) func(*Client) {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// Use own credentials value, signaling configs returned by client use them too
+func WithCredentialsValue(value *credentials.Value) func(*Client) {
+	return func(c *Client) {
+		c.credentialsValue = value
+	}
+}
+
+// Use own credentials provider for control plane requests, pass it to signaling clients
+// with signaling.WithCredentialsProvider
+func WithCredentialsProvider(provider credentials.Provider) func(*Client) {
+	return func(c *Client) {
+		c.credentialsProvider = provider
+	}
+}
+
+// New control plane client for input region
+func New(region string, options ...func(*Client)) (*Client, error) {
+	// Region must never be empty
+	if region == "" {
+		return nil, ErrInvalidRegion
+	}
+
+	// New client with initial values
+	c := &Client{
+		region:     region,
+		endpoint:   defaultEndpoint(region),
+		httpClient: http.DefaultClient,
+	}
+
+	// Getting other optional parameters
+	for _, o := range options {
+		o(c)
+	}
+
+	// If you are not using our signer
+	if c.signer == nil {
+		signerOptions := []func(*signerV4.Signer){signerV4.WithRegion(region), signerV4.WithService(service)}
+		// Do you have own Credentials ?
+		if c.credentialsProvider != nil {
+			signerOptions = append(signerOptions, signerV4.WithCredentialsProvider(c.credentialsProvider))
+		} else if c.credentialsValue != nil {
+			signerOptions = append(signerOptions, signerV4.WithCredentialsValue(c.credentialsValue))
+		}
+		kinesisVideoSigner, err := signerV4.New(signerOptions...)
+		if err != nil {
+			return nil, err
+		}
+		c.signer = kinesisVideoSigner
+	}
+
+	return c, nil
+}
+
+// Control plane endpoint of a region
+func defaultEndpoint(region string) string {
+	endpoint := "https://kinesisvideo." + region + ".amazonaws.com"
+	// China regions have their own domain
+	if strings.HasPrefix(region, "cn-") {
+		endpoint += ".cn"
+	}
+	return endpoint
+}
+
+// ServerTime returns AWS clock from Date header of an unsigned control plane request, so it works
+// with a skewed local clock. Use it as signaling.WithServerTime
+func (c *Client) ServerTime(ctx context.Context) (time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.endpoint, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	// Any response has a date, even errors
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("control plane response has no valid date: %w", err)
+	}
+	return date, nil
+}
+
+// Call operation at input url with input as json body, json response is decoded into output
+func (c *Client) call(ctx context.Context, url string, input interface{}, output interface{}) error {
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	// Signed json request
+	body := bytes.NewReader(payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err = c.signer.SignRequest(req, body, nil); err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// if something wrong happened
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp)
+	}
+
+	// Operations without output
+	if output == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(output)
+}
+
+// Read control plane error response
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	// Error type header looks like "ResourceNotFoundException:http://internal.amazon.com/..."
+	apiErr.Code, _, _ = strings.Cut(resp.Header.Get("X-Amzn-Errortype"), ":")
+
+	// Body has the message, and the type when header is missing
+	var body struct {
+		Type           string `json:"__type"`
+		Message        string `json:"message"`
+		MessageCapital string `json:"Message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if json.Unmarshal(data, &body) == nil {
+		apiErr.Message = body.Message
+		if apiErr.Message == "" {
+			apiErr.Message = body.MessageCapital
+		}
+		// Type may have a namespace, like "com.amazonaws.kinesisvideo#ResourceNotFoundException"
+		if apiErr.Code == "" {
+			apiErr.Code = body.Type[strings.LastIndex(body.Type, "#")+1:]
+		}
+	}
+	return apiErr
+}
@@ -0,0 +1,156 @@
+package channel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
+)
+
+// Signaling channel status when it can be used
+const StatusActive = "ACTIVE"
+
+// Signaling channel endpoint protocols
+const (
+	ProtocolWSS   = "WSS"
+	ProtocolHTTPS = "HTTPS"
+)
+
+// Signaling channel description
+type ChannelInfo struct {
+	ChannelARN    string        // ARN AWS Signaling Channel
+	ChannelName   string        // Signaling channel name
+	ChannelStatus string        // CREATING, ACTIVE, UPDATING or DELETING
+	ChannelType   string        // SINGLE_MASTER or FULL_MESH
+	CreationTime  time.Time     // When channel was created
+	MessageTTL    time.Duration // How long undelivered messages are kept
+	Version       string        // Current channel version
+}
+
+// Signaling channel endpoints for a role
+type Endpoints struct {
+	WSS   string // WSS URL AWS signaling
+	HTTPS string // HTTPS URL AWS signaling
+}
+
+// Channel description as returned by control plane
+type channelInfoJSON struct {
+	ChannelARN                string  `json:"ChannelARN"`
+	ChannelName               string  `json:"ChannelName"`
+	ChannelStatus             string  `json:"ChannelStatus"`
+	ChannelType               string  `json:"ChannelType"`
+	CreationTime              float64 `json:"CreationTime"`
+	SingleMasterConfiguration *struct {
+		MessageTtlSeconds int64 `json:"MessageTtlSeconds"`
+	} `json:"SingleMasterConfiguration"`
+	Version string `json:"Version"`
+}
+
+// Typed channel description
+func (i *channelInfoJSON) info() *ChannelInfo {
+	info := &ChannelInfo{
+		ChannelARN:    i.ChannelARN,
+		ChannelName:   i.ChannelName,
+		ChannelStatus: i.ChannelStatus,
+		ChannelType:   i.ChannelType,
+		CreationTime:  time.UnixMilli(int64(i.CreationTime * 1000)),
+		Version:       i.Version,
+	}
+	if i.SingleMasterConfiguration != nil {
+		info.MessageTTL = time.Duration(i.SingleMasterConfiguration.MessageTtlSeconds) * time.Second
+	}
+	return info
+}
+
+// Tells if input is a channel ARN instead of a channel name
+func isARN(nameOrARN string) bool {
+	return strings.HasPrefix(nameOrARN, "arn:")
+}
+
+// DescribeSignalingChannel returns description of a signaling channel, by name or ARN
+func (c *Client) DescribeSignalingChannel(ctx context.Context, nameOrARN string) (*ChannelInfo, error) {
+	input := map[string]string{"ChannelName": nameOrARN}
+	if isARN(nameOrARN) {
+		input = map[string]string{"ChannelARN": nameOrARN}
+	}
+
+	var output struct {
+		ChannelInfo channelInfoJSON `json:"ChannelInfo"`
+	}
+	if err := c.call(ctx, c.endpoint+"/describeSignalingChannel", input, &output); err != nil {
+		return nil, err
+	}
+	return output.ChannelInfo.info(), nil
+}
+
+// GetSignalingChannelEndpoint returns WSS and HTTPS endpoints of a signaling channel for input role
+func (c *Client) GetSignalingChannelEndpoint(ctx context.Context, channelARN string, role signaling.Role) (*Endpoints, error) {
+	input := map[string]interface{}{
+		"ChannelARN": channelARN,
+		"SingleMasterChannelEndpointConfiguration": map[string]interface{}{
+			"Protocols": []string{ProtocolWSS, ProtocolHTTPS},
+			"Role":      role,
+		},
+	}
+
+	var output struct {
+		ResourceEndpointList []struct {
+			Protocol         string `json:"Protocol"`
+			ResourceEndpoint string `json:"ResourceEndpoint"`
+		} `json:"ResourceEndpointList"`
+	}
+	if err := c.call(ctx, c.endpoint+"/getSignalingChannelEndpoint", input, &output); err != nil {
+		return nil, err
+	}
+
+	endpoints := &Endpoints{}
+	for _, e := range output.ResourceEndpointList {
+		switch e.Protocol {
+		case ProtocolWSS:
+			endpoints.WSS = e.ResourceEndpoint
+		case ProtocolHTTPS:
+			endpoints.HTTPS = e.ResourceEndpoint
+		}
+	}
+
+	// WSS endpoint is required for signaling
+	if endpoints.WSS == "" {
+		return nil, fmt.Errorf("%w: %s", ErrNoEndpoint, ProtocolWSS)
+	}
+	return endpoints, nil
+}
+
+// SignalingConfig describes signaling channel, by name or ARN, and returns a signaling config for
+// input role with its endpoints. ClientID must be nil for master
+func (c *Client) SignalingConfig(ctx context.Context, nameOrARN string, role signaling.Role, clientID *string) (*signaling.Config, error) {
+	info, err := c.DescribeSignalingChannel(ctx, nameOrARN)
+	if err != nil {
+		return nil, err
+	}
+
+	// Channel is being created, updated or deleted
+	if info.ChannelStatus != StatusActive {
+		return nil, fmt.Errorf("%w: %s is %s", ErrChannelNotActive, info.ChannelARN, info.ChannelStatus)
+	}
+
+	endpoints, err := c.GetSignalingChannelEndpoint(ctx, info.ChannelARN, role)
+	if err != nil {
+		return nil, err
+	}
+
+	region := c.region
+	config := &signaling.Config{
+		ChannelARN:       &info.ChannelARN,
+		ChannelEndpoint:  &endpoints.WSS,
+		Region:           &region,
+		Role:             role,
+		ClientID:         clientID,
+		CredentialsValue: c.credentialsValue,
+	}
+	if endpoints.HTTPS != "" {
+		config.HTTPSEndpoint = &endpoints.HTTPS
+	}
+	return config, nil
+}
@@ -0,0 +1,48 @@
+package channel
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Channel errors, match them with errors.Is
+var (
+	// Region is empty
+	ErrInvalidRegion = errors.New("region cannot be empty")
+	// Signaling channel does not exist
+	ErrChannelNotFound = errors.New("signaling channel not found")
+	// Signaling channel name is in use already
+	ErrChannelExists = errors.New("signaling channel already exists")
+	// Signaling channel version is not current version, it was updated meanwhile
+	ErrVersionMismatch = errors.New("signaling channel version mismatch")
+	// Signaling channel exists but it can not be used yet or anymore
+	ErrChannelNotActive = errors.New("signaling channel is not active")
+	// Control plane did not return an endpoint for a protocol
+	ErrNoEndpoint = errors.New("signaling channel endpoint not returned")
+)
+
+// Error returned by Kinesis Video control plane
+type APIError struct {
+	StatusCode int    // Http status code
+	Code       string // Error type, like ResourceNotFoundException
+	Message    string // Error message
+}
+
+// Error message with status, type and message
+func (e *APIError) Error() string {
+	return "kinesis video status " + strconv.Itoa(e.StatusCode) + " " + e.Code + ": " + e.Message
+}
+
+// APIError of a missing resource is an ErrChannelNotFound, of a resource in use an ErrChannelExists
+// and of an old version an ErrVersionMismatch
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrChannelNotFound:
+		return e.Code == "ResourceNotFoundException"
+	case ErrChannelExists:
+		return e.Code == "ResourceInUseException"
+	case ErrVersionMismatch:
+		return e.Code == "VersionMismatchException"
+	}
+	return false
+}
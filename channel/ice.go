@@ -0,0 +1,346 @@
+package channel
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
+)
+
+// Default time before ICE server credentials expire when they are refreshed
+const DefaultIceServerRefreshWindow = 30 * time.Second
+
+// Default wait before a failed ICE server refresh is tried again
+const DefaultIceServerRetryDelay = 5 * time.Second
+
+// Default time TURN servers without TTL, or an empty TURN server list, are cached
+const DefaultIceServerCacheTTL = 5 * time.Minute
+
+// Default time limit of a TURN server fetch, it does not depend on callers waiting for it
+const DefaultIceServerFetchTimeout = 10 * time.Second
+
+// ICE server of a signaling channel, fields match pion webrtc.ICEServer
+type IceServer struct {
+	URLs       []string      // stun:, turn: or turns: urls
+	Username   string        // TURN username, empty for STUN
+	Credential string        // TURN password, empty for STUN
+	TTL        time.Duration // How long TURN credentials are valid, 0 for STUN
+}
+
+// STUNServer returns Kinesis Video STUN server of a region
+func STUNServer(region string) IceServer {
+	host := "stun.kinesisvideo." + region + ".amazonaws.com"
+	// China regions have their own domain
+	if strings.HasPrefix(region, "cn-") {
+		host += ".cn"
+	}
+	return IceServer{URLs: []string{"stun:" + host + ":443"}}
+}
+
+// GetIceServerConfig returns TURN servers of a signaling channel from its HTTPS endpoint.
+// ClientID is optional, viewers use their own
+func (c *Client) GetIceServerConfig(ctx context.Context, httpsEndpoint string, channelARN string, clientID string) ([]IceServer, error) {
+	input := map[string]string{"ChannelARN": channelARN, "Service": "TURN"}
+	if clientID != "" {
+		input["ClientId"] = clientID
+	}
+
+	var output struct {
+		IceServerList []struct {
+			Password string   `json:"Password"`
+			Ttl      int64    `json:"Ttl"`
+			Uris     []string `json:"Uris"`
+			Username string   `json:"Username"`
+		} `json:"IceServerList"`
+	}
+	if err := c.call(ctx, httpsEndpoint+"/v1/get-ice-server-config", input, &output); err != nil {
+		return nil, err
+	}
+
+	servers := make([]IceServer, 0, len(output.IceServerList))
+	for _, s := range output.IceServerList {
+		servers = append(servers, IceServer{
+			URLs:       s.Uris,
+			Username:   s.Username,
+			Credential: s.Password,
+			TTL:        time.Duration(s.Ttl) * time.Second,
+		})
+	}
+	return servers, nil
+}
+
+// ICE servers of a signaling channel, STUN server of its region and cached TURN servers.
+// TURN servers are refreshed before their credentials expire and when signaling service asks for it
+type IceServers struct {
+	client        *Client                   // Client calling GetIceServerConfig
+	region        string                    // AWS Region, for STUN server
+	endpoint      string                    // HTTPS URL AWS signaling
+	channelARN    string                    // ARN AWS Signaling Channel
+	clientID      string                    // Viewer client id, empty for master
+	refreshWindow time.Duration             // Time before expiry when TURN servers are refreshed
+	retryDelay    time.Duration             // Wait before a failed refresh is tried again
+	cacheTTL      time.Duration             // How long TURN servers without TTL are cached
+	fetchTimeout  time.Duration             // Time limit of a TURN server fetch
+	servers       []IceServer               // Cached TURN servers
+	expiresAt     time.Time                 // When first cached TURN credentials expire
+	fetches       uint64                    // Fetches started
+	cachedFetch   uint64                    // Fetch of cached TURN servers, older responses are ignored
+	inflight      *iceServersRefresh        // Refresh in progress, other refreshes wait for it
+	timer         *time.Timer               // Timer for next refresh
+	closed        bool                      // No more refreshes
+	onUpdate      func(servers []IceServer) // Function for Update Event
+	onError       func(err error)           // Function for Error Event
+	mu            sync.Mutex                // Protect cache, timer and event functions
+}
+
+// Refresh in progress and its result, ready when done is closed
+type iceServersRefresh struct {
+	done    chan struct{} // Closed when refresh finishes
+	servers []IceServer   // STUN server and TURN servers
+	err     error         // Refresh error
+}
+
+// Use own time before expiry when TURN servers are refreshed
+func WithRefreshWindow(window time.Duration) func(*IceServers) {
+	return func(s *IceServers) {
+		s.refreshWindow = window
+	}
+}
+
+// Use own wait before a failed refresh is tried again
+func WithRetryDelay(delay time.Duration) func(*IceServers) {
+	return func(s *IceServers) {
+		s.retryDelay = delay
+	}
+}
+
+// Use own time TURN servers without TTL, or an empty TURN server list, are cached. It must be
+// longer than refresh window, otherwise every Get fetches them
+func WithCacheTTL(ttl time.Duration) func(*IceServers) {
+	return func(s *IceServers) {
+		s.cacheTTL = ttl
+	}
+}
+
+// Use own time limit of a TURN server fetch, callers may give up waiting for it earlier
+func WithFetchTimeout(timeout time.Duration) func(*IceServers) {
+	return func(s *IceServers) {
+		s.fetchTimeout = timeout
+	}
+}
+
+// New ICE servers of the signaling channel of input signaling config, it needs its HTTPS endpoint
+func NewIceServers(client *Client, config *signaling.Config, options ...func(*IceServers)) (*IceServers, error) {
+	// Config must never be nil
+	if config == nil {
+		return nil, &signaling.ConfigError{Field: "Config", Reason: "cannot be nil"}
+	}
+
+	// Config ChannelARN must never be nil
+	if config.ChannelARN == nil {
+		return nil, &signaling.ConfigError{Field: "channelARN", Reason: "cannot be nil"}
+	}
+
+	// Config HTTPSEndpoint must never be nil
+	if config.HTTPSEndpoint == nil {
+		return nil, &signaling.ConfigError{Field: "httpsEndpoint", Reason: "cannot be nil"}
+	}
+
+	// New ICE servers with initial values
+	s := &IceServers{
+		client:        client,
+		region:        client.region,
+		endpoint:      *config.HTTPSEndpoint,
+		channelARN:    *config.ChannelARN,
+		refreshWindow: DefaultIceServerRefreshWindow,
+		retryDelay:    DefaultIceServerRetryDelay,
+		cacheTTL:      DefaultIceServerCacheTTL,
+		fetchTimeout:  DefaultIceServerFetchTimeout,
+	}
+	if config.Region != nil {
+		s.region = *config.Region
+	}
+	if config.ClientID != nil {
+		s.clientID = *config.ClientID
+	}
+
+	// Getting other optional parameters
+	for _, o := range options {
+		o(s)
+	}
+
+	return s, nil
+}
+
+// On Update Event Function, triggered when TURN servers are refreshed with all ICE servers
+func (s *IceServers) OnUpdate(f func(servers []IceServer)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onUpdate = f
+}
+
+// OnError Event Function, triggered when a background refresh fails
+func (s *IceServers) OnError(f func(err error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onError = f
+}
+
+// Get returns STUN server and TURN servers, they are fetched when cache is empty or about to expire
+func (s *IceServers) Get(ctx context.Context) ([]IceServer, error) {
+	s.mu.Lock()
+	if s.servers != nil && time.Until(s.expiresAt) > s.refreshWindow {
+		servers := s.listLocked()
+		s.mu.Unlock()
+		return servers, nil
+	}
+	s.mu.Unlock()
+
+	return s.refresh(ctx)
+}
+
+// Cached returns STUN server and cached TURN servers without fetching them, TURN credentials may be expired
+func (s *IceServers) Cached() []IceServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listLocked()
+}
+
+// Refresh fetches TURN servers again
+func (s *IceServers) Refresh(ctx context.Context) error {
+	_, err := s.refresh(ctx)
+	return err
+}
+
+// On Reconnect Ice Server Event Function for signaling client, TURN servers are refreshed in background:
+// signalingClient.OnReconnectIceServer(iceServers.OnReconnectIceServer)
+func (s *IceServers) OnReconnectIceServer(msg *signaling.ReconnectIceServer) {
+	go s.backgroundRefresh()
+}
+
+// Close stops background refreshes
+func (s *IceServers) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
+
+// Fetch TURN servers, cache them and schedule next refresh. Refreshes in progress are shared,
+// so concurrent callers wait for a single fetch, each one until its own context is done
+func (s *IceServers) refresh(ctx context.Context) ([]IceServer, error) {
+	s.mu.Lock()
+	r := s.inflight
+	// No refresh in progress, start one
+	if r == nil {
+		r = &iceServersRefresh{done: make(chan struct{})}
+		s.inflight = r
+		s.fetches++
+		go s.runRefresh(r, s.fetches)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.servers, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Shared fetch, detached from callers so one giving up does not fail the others
+func (s *IceServers) runRefresh(r *iceServersRefresh, fetch uint64) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.fetchTimeout)
+	defer cancel()
+
+	r.servers, r.err = s.fetch(ctx, fetch)
+
+	s.mu.Lock()
+	s.inflight = nil
+	s.mu.Unlock()
+	close(r.done)
+}
+
+// Fetch TURN servers and cache them unless cache has a newer response
+func (s *IceServers) fetch(ctx context.Context, fetch uint64) ([]IceServer, error) {
+	turnServers, err := s.client.GetIceServerConfig(ctx, s.endpoint, s.channelARN, s.clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Credentials of first expiring server rule the cache
+	now := time.Now()
+	expiresAt := time.Time{}
+	for _, server := range turnServers {
+		if server.TTL > 0 && (expiresAt.IsZero() || now.Add(server.TTL).Before(expiresAt)) {
+			expiresAt = now.Add(server.TTL)
+		}
+	}
+
+	s.mu.Lock()
+	// Response older than cached one
+	if fetch < s.cachedFetch {
+		servers := s.listLocked()
+		s.mu.Unlock()
+		return servers, nil
+	}
+	s.cachedFetch = fetch
+	s.servers = turnServers
+	if expiresAt.IsZero() {
+		// Nothing expires, they are fetched again by Get when cache TTL is over
+		s.expiresAt = now.Add(s.cacheTTL)
+	} else {
+		s.expiresAt = expiresAt
+		s.scheduleLocked(time.Until(expiresAt) - s.refreshWindow)
+	}
+	servers := s.listLocked()
+	f := s.onUpdate
+	s.mu.Unlock()
+
+	if f != nil {
+		f(servers)
+	}
+	return servers, nil
+}
+
+// Refresh without caller, failures are reported by Error Event and tried again
+func (s *IceServers) backgroundRefresh() {
+	// if something wrong happened
+	if _, err := s.refresh(context.Background()); err != nil {
+		s.mu.Lock()
+		s.scheduleLocked(s.retryDelay)
+		f := s.onError
+		s.mu.Unlock()
+
+		if f != nil {
+			f(err)
+		}
+	}
+}
+
+// Schedule next background refresh, lock must be held by caller
+func (s *IceServers) scheduleLocked(delay time.Duration) {
+	if s.closed {
+		return
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.timer = time.AfterFunc(delay, s.backgroundRefresh)
+}
+
+// STUN server and cached TURN servers, lock must be held by caller
+func (s *IceServers) listLocked() []IceServer {
+	servers := make([]IceServer, 0, len(s.servers)+1)
+	servers = append(servers, STUNServer(s.region))
+	return append(servers, s.servers...)
+}
@@ -0,0 +1,236 @@
+package channel_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/channel"
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
+	"github.com/stretchr/testify/assert"
+)
+
+// Signaling channel HTTPS endpoint stand-in, it counts GetIceServerConfig calls
+func newIceServerEndpoint(t *testing.T, ttl int, calls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/get-ice-server-config", r.URL.Path)
+
+		// Check request body
+		body := map[string]string{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]string{"ChannelARN": channelARN, "ClientId": clientID, "Service": "TURN"}, body)
+
+		calls.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"IceServerList": []map[string]interface{}{{
+				"Password": "password",
+				"Ttl":      ttl,
+				"Uris":     []string{"turn:1-2-3-4.t-1234.kinesisvideo.us-west-2.amazonaws.com:443?transport=udp"},
+				"Username": "username",
+			}},
+		})
+	}))
+}
+
+// New ICE servers of a viewer over stand-in
+func newIceServers(t *testing.T, server *httptest.Server, options ...func(*channel.IceServers)) *channel.IceServers {
+	config := &signaling.Config{ChannelARN: &channelARN, Region: &REGION, HTTPSEndpoint: &server.URL, ClientID: &clientID, Role: signaling.Viewer}
+	iceServers, err := channel.NewIceServers(newClient(t, server), config, options...)
+
+	// if something wrong happened
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return iceServers
+}
+
+// Testing ICE servers are cached until they are about to expire
+func TestIceServersGet(t *testing.T) {
+	var calls atomic.Int32
+	server := newIceServerEndpoint(t, 300, &calls)
+	defer server.Close()
+
+	iceServers := newIceServers(t, server)
+	defer iceServers.Close()
+
+	// Get ICE servers twice
+	servers, err := iceServers.Get(context.Background())
+	assert.NoError(t, err)
+	cached, err := iceServers.Get(context.Background())
+	assert.NoError(t, err)
+
+	// ASSERTS
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, servers, cached)
+	assert.Equal(t, []channel.IceServer{
+		{URLs: []string{"stun:stun.kinesisvideo.us-west-2.amazonaws.com:443"}},
+		{
+			URLs:       []string{"turn:1-2-3-4.t-1234.kinesisvideo.us-west-2.amazonaws.com:443?transport=udp"},
+			Username:   "username",
+			Credential: "password",
+			TTL:        5 * time.Minute,
+		},
+	}, servers)
+}
+
+// Testing ICE servers are refreshed before they expire
+func TestIceServersRefreshBeforeExpiry(t *testing.T) {
+	var calls atomic.Int32
+	server := newIceServerEndpoint(t, 1, &calls)
+	defer server.Close()
+
+	// Refresh 100ms after every fetch
+	iceServers := newIceServers(t, server, channel.WithRefreshWindow(900*time.Millisecond))
+	defer iceServers.Close()
+
+	// Create channel for control flow
+	c := make(chan string, 10)
+	iceServers.OnUpdate(func(servers []channel.IceServer) {
+		c <- "update"
+	})
+
+	// First fetch
+	_, err := iceServers.Get(context.Background())
+	assert.NoError(t, err)
+	<-c
+
+	// ASSERTS
+	select {
+	case <-c:
+	case <-time.After(time.Second):
+		t.Fatalf("ICE servers were not refreshed")
+	}
+	assert.GreaterOrEqual(t, calls.Load(), int32(2))
+}
+
+// Testing ICE servers are refreshed when signaling service asks for it
+func TestIceServersReconnectIceServer(t *testing.T) {
+	var calls atomic.Int32
+	server := newIceServerEndpoint(t, 300, &calls)
+	defer server.Close()
+
+	iceServers := newIceServers(t, server)
+	defer iceServers.Close()
+
+	// Create channel for control flow
+	c := make(chan string, 10)
+	iceServers.OnUpdate(func(servers []channel.IceServer) {
+		c <- "update"
+	})
+
+	// Signaling service message
+	iceServers.OnReconnectIceServer(&signaling.ReconnectIceServer{})
+
+	// ASSERTS
+	select {
+	case <-c:
+	case <-time.After(time.Second):
+		t.Fatalf("ICE servers were not refreshed")
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// Testing ICE servers without TTL are cached too
+func TestIceServersGetWithoutTTL(t *testing.T) {
+	var calls atomic.Int32
+	server := newIceServerEndpoint(t, 0, &calls)
+	defer server.Close()
+
+	iceServers := newIceServers(t, server)
+	defer iceServers.Close()
+
+	// Get ICE servers twice
+	servers, err := iceServers.Get(context.Background())
+	assert.NoError(t, err)
+	cached, err := iceServers.Get(context.Background())
+
+	// ASSERTS
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, servers, cached)
+	assert.Equal(t, servers, iceServers.Cached())
+}
+
+// Testing concurrent refreshes share a single fetch
+func TestIceServersConcurrentRefresh(t *testing.T) {
+	// Slow signaling channel HTTPS endpoint stand-in
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		_, _ = w.Write([]byte(`{"IceServerList":[{"Password":"password","Ttl":300,"Uris":["turn:127.0.0.1:3478"],"Username":"username"}]}`))
+	}))
+	defer server.Close()
+
+	iceServers := newIceServers(t, server)
+	defer iceServers.Close()
+
+	// Callers and signaling service ask for ICE servers at the same time
+	results := make(chan []channel.IceServer, 3)
+	for i := 0; i < 2; i++ {
+		go func() {
+			servers, err := iceServers.Get(context.Background())
+			assert.NoError(t, err)
+			results <- servers
+		}()
+	}
+	go func() {
+		assert.NoError(t, iceServers.Refresh(context.Background()))
+		results <- iceServers.Cached()
+	}()
+	assert.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	// ASSERTS
+	for i := 0; i < 3; i++ {
+		assert.Len(t, <-results, 2)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// Testing a caller giving up does not fail other callers waiting for the same fetch
+func TestIceServersRefreshCallerCancelled(t *testing.T) {
+	// Slow signaling channel HTTPS endpoint stand-in
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		_, _ = w.Write([]byte(`{"IceServerList":[{"Password":"password","Ttl":300,"Uris":["turn:127.0.0.1:3478"],"Username":"username"}]}`))
+	}))
+	defer server.Close()
+
+	iceServers := newIceServers(t, server)
+	defer iceServers.Close()
+
+	// First caller gives up before signaling service answers
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	results := make(chan []channel.IceServer, 1)
+	go func() {
+		assert.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+		servers, err := iceServers.Get(context.Background())
+		assert.NoError(t, err)
+		results <- servers
+	}()
+	_, err := iceServers.Get(ctx)
+	close(release)
+
+	// ASSERTS
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Len(t, <-results, 2)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// Testing ICE servers need HTTPS endpoint
+func TestNewIceServersWithoutHTTPSEndpoint(t *testing.T) {
+	client, _ := channel.New(REGION, channel.WithCredentialsValue(&credentialsValue))
+	_, err := channel.NewIceServers(client, &signaling.Config{ChannelARN: &channelARN})
+	assert.ErrorIs(t, err, signaling.ErrInvalidConfig)
+}
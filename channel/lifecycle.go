@@ -0,0 +1,178 @@
+package channel
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Signaling channel types
+const (
+	TypeSingleMaster = "SINGLE_MASTER"
+	TypeFullMesh     = "FULL_MESH"
+)
+
+// Input of CreateSignalingChannel
+type CreateInput struct {
+	ChannelName string            // Signaling channel name, unique in account and region
+	ChannelType string            // Signaling channel type, SINGLE_MASTER when empty
+	MessageTTL  time.Duration     // How long undelivered messages are kept, service default when 0
+	Tags        map[string]string // Signaling channel tags
+}
+
+// Input of ListSignalingChannels
+type ListInput struct {
+	NamePrefix string // Only channels with a name that begins with it, all channels when empty
+	MaxResults int    // Max channels in page, service default when 0
+	NextToken  string // Token of page to list, first page when empty
+}
+
+// Tag as sent to control plane
+type tagJSON struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+// Tags sorted by key as sent to control plane
+func tagList(tags map[string]string) []tagJSON {
+	list := make([]tagJSON, 0, len(tags))
+	for k, v := range tags {
+		list = append(list, tagJSON{Key: k, Value: v})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Key < list[j].Key
+	})
+	return list
+}
+
+// Message ttl as sent to control plane
+func singleMasterConfiguration(messageTTL time.Duration) map[string]int64 {
+	return map[string]int64{"MessageTtlSeconds": int64(messageTTL / time.Second)}
+}
+
+// CreateSignalingChannel creates a signaling channel and returns its ARN
+func (c *Client) CreateSignalingChannel(ctx context.Context, input CreateInput) (string, error) {
+	channelType := input.ChannelType
+	if channelType == "" {
+		channelType = TypeSingleMaster
+	}
+
+	request := map[string]interface{}{
+		"ChannelName": input.ChannelName,
+		"ChannelType": channelType,
+	}
+	if input.MessageTTL > 0 {
+		request["SingleMasterConfiguration"] = singleMasterConfiguration(input.MessageTTL)
+	}
+	if len(input.Tags) > 0 {
+		request["Tags"] = tagList(input.Tags)
+	}
+
+	var output struct {
+		ChannelARN string `json:"ChannelARN"`
+	}
+	if err := c.call(ctx, c.endpoint+"/createSignalingChannel", request, &output); err != nil {
+		return "", err
+	}
+	return output.ChannelARN, nil
+}
+
+// DeleteSignalingChannel deletes a signaling channel. CurrentVersion is optional, when it is given
+// channel is deleted only if it was not updated meanwhile
+func (c *Client) DeleteSignalingChannel(ctx context.Context, channelARN string, currentVersion string) error {
+	request := map[string]string{"ChannelARN": channelARN}
+	if currentVersion != "" {
+		request["CurrentVersion"] = currentVersion
+	}
+	return c.call(ctx, c.endpoint+"/deleteSignalingChannel", request, nil)
+}
+
+// UpdateSignalingChannel changes message ttl of a signaling channel, currentVersion comes from its description
+func (c *Client) UpdateSignalingChannel(ctx context.Context, channelARN string, currentVersion string, messageTTL time.Duration) error {
+	request := map[string]interface{}{
+		"ChannelARN":                channelARN,
+		"CurrentVersion":            currentVersion,
+		"SingleMasterConfiguration": singleMasterConfiguration(messageTTL),
+	}
+	return c.call(ctx, c.endpoint+"/updateSignalingChannel", request, nil)
+}
+
+// ListSignalingChannels returns a page of signaling channels and token of next page, empty on last page
+func (c *Client) ListSignalingChannels(ctx context.Context, input ListInput) ([]*ChannelInfo, string, error) {
+	request := map[string]interface{}{}
+	if input.NamePrefix != "" {
+		request["ChannelNameCondition"] = map[string]string{"ComparisonOperator": "BEGINS_WITH", "ComparisonValue": input.NamePrefix}
+	}
+	if input.MaxResults > 0 {
+		request["MaxResults"] = input.MaxResults
+	}
+	if input.NextToken != "" {
+		request["NextToken"] = input.NextToken
+	}
+
+	var output struct {
+		ChannelInfoList []channelInfoJSON `json:"ChannelInfoList"`
+		NextToken       string            `json:"NextToken"`
+	}
+	if err := c.call(ctx, c.endpoint+"/listSignalingChannels", request, &output); err != nil {
+		return nil, "", err
+	}
+
+	channels := make([]*ChannelInfo, 0, len(output.ChannelInfoList))
+	for i := range output.ChannelInfoList {
+		channels = append(channels, output.ChannelInfoList[i].info())
+	}
+	return channels, output.NextToken, nil
+}
+
+// ListAllSignalingChannels returns signaling channels of every page, with a name that begins with namePrefix
+func (c *Client) ListAllSignalingChannels(ctx context.Context, namePrefix string) ([]*ChannelInfo, error) {
+	var channels []*ChannelInfo
+	input := ListInput{NamePrefix: namePrefix}
+	for {
+		page, nextToken, err := c.ListSignalingChannels(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, page...)
+
+		// Last page
+		if nextToken == "" {
+			return channels, nil
+		}
+		input.NextToken = nextToken
+	}
+}
+
+// TagResource adds tags to a signaling channel, existing tags with same keys are overwritten
+func (c *Client) TagResource(ctx context.Context, resourceARN string, tags map[string]string) error {
+	request := map[string]interface{}{
+		"ResourceARN": resourceARN,
+		"Tags":        tagList(tags),
+	}
+	return c.call(ctx, c.endpoint+"/TagResource", request, nil)
+}
+
+// ListTagsForResource returns tags of a signaling channel, of every page
+func (c *Client) ListTagsForResource(ctx context.Context, resourceARN string) (map[string]string, error) {
+	tags := map[string]string{}
+	request := map[string]string{"ResourceARN": resourceARN}
+	for {
+		var output struct {
+			NextToken string            `json:"NextToken"`
+			Tags      map[string]string `json:"Tags"`
+		}
+		if err := c.call(ctx, c.endpoint+"/ListTagsForResource", request, &output); err != nil {
+			return nil, err
+		}
+		for k, v := range output.Tags {
+			tags[k] = v
+		}
+
+		// Last page
+		if output.NextToken == "" {
+			return tags, nil
+		}
+		request["NextToken"] = output.NextToken
+	}
+}
@@ -0,0 +1,262 @@
+package channel_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/channel"
+	"github.com/stretchr/testify/assert"
+)
+
+// Signaling channel kept by fake control plane
+type fakeChannel struct {
+	ChannelARN                string
+	ChannelName               string
+	ChannelStatus             string
+	ChannelType               string
+	CreationTime              float64
+	SingleMasterConfiguration map[string]int64
+	Version                   string
+	tags                      map[string]string
+}
+
+// Local fake of Kinesis Video control plane, pages have two items
+type fakeControlPlane struct {
+	channels map[string]*fakeChannel // Channels by ARN
+	version  int                     // Last channel version
+	mu       sync.Mutex
+}
+
+// Request body of every operation
+type fakeRequest struct {
+	ChannelName               string
+	ChannelARN                string
+	ResourceARN               string
+	ChannelType               string
+	CurrentVersion            string
+	NextToken                 string
+	MaxResults                int
+	SingleMasterConfiguration map[string]int64
+	ChannelNameCondition      map[string]string
+	Tags                      []map[string]string
+}
+
+// Write control plane error
+func fakeError(w http.ResponseWriter, status int, code string, message string) {
+	w.Header().Set("X-Amzn-Errortype", code+":http://internal.amazon.com/coral/com.amazonaws.kinesisvideo/")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"Message": message})
+}
+
+// Next version, lock must be held
+func (f *fakeControlPlane) nextVersion() string {
+	f.version++
+	return strconv.Itoa(f.version)
+}
+
+// Page of sorted keys from token, it returns next token
+func page(keys []string, token string) ([]string, string) {
+	sort.Strings(keys)
+	start, _ := strconv.Atoi(token)
+	end := start + 2
+	if end >= len(keys) {
+		return keys[start:], ""
+	}
+	return keys[start:end], strconv.Itoa(end)
+}
+
+// Handle control plane operations
+func (f *fakeControlPlane) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var req fakeRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	output := map[string]interface{}{}
+	found := true
+
+	switch r.URL.Path {
+	case "/createSignalingChannel":
+		arn := "arn:aws:kinesisvideo:us-west-2:123456789012:channel/" + req.ChannelName + "/1234567890"
+		if f.channels[arn] != nil {
+			fakeError(w, http.StatusBadRequest, "ResourceInUseException", "The signalingChannel is currently not available for this operation.")
+			return
+		}
+		ch := &fakeChannel{ChannelARN: arn, ChannelName: req.ChannelName, ChannelStatus: "ACTIVE", ChannelType: req.ChannelType,
+			CreationTime: 1575158400, SingleMasterConfiguration: map[string]int64{"MessageTtlSeconds": 60}, Version: f.nextVersion(), tags: map[string]string{}}
+		if req.SingleMasterConfiguration != nil {
+			ch.SingleMasterConfiguration = req.SingleMasterConfiguration
+		}
+		for _, tag := range req.Tags {
+			ch.tags[tag["Key"]] = tag["Value"]
+		}
+		f.channels[arn] = ch
+		output["ChannelARN"] = arn
+	case "/describeSignalingChannel":
+		found = false
+		for _, ch := range f.channels {
+			if ch.ChannelARN == req.ChannelARN || ch.ChannelName == req.ChannelName {
+				output["ChannelInfo"] = ch
+				found = true
+			}
+		}
+	case "/updateSignalingChannel":
+		ch := f.channels[req.ChannelARN]
+		found = ch != nil
+		if found && ch.Version != req.CurrentVersion {
+			fakeError(w, http.StatusBadRequest, "VersionMismatchException", "The version of the signaling channel does not match.")
+			return
+		}
+		if found {
+			ch.SingleMasterConfiguration = req.SingleMasterConfiguration
+			ch.Version = f.nextVersion()
+		}
+	case "/deleteSignalingChannel":
+		found = f.channels[req.ChannelARN] != nil
+		delete(f.channels, req.ChannelARN)
+	case "/listSignalingChannels":
+		keys := []string{}
+		for arn, ch := range f.channels {
+			if req.ChannelNameCondition == nil || strings.HasPrefix(ch.ChannelName, req.ChannelNameCondition["ComparisonValue"]) {
+				keys = append(keys, arn)
+			}
+		}
+		arns, next := page(keys, req.NextToken)
+		list := []*fakeChannel{}
+		for _, arn := range arns {
+			list = append(list, f.channels[arn])
+		}
+		output["ChannelInfoList"] = list
+		output["NextToken"] = next
+	case "/TagResource":
+		ch := f.channels[req.ResourceARN]
+		found = ch != nil
+		if found {
+			for _, tag := range req.Tags {
+				ch.tags[tag["Key"]] = tag["Value"]
+			}
+		}
+	case "/ListTagsForResource":
+		ch := f.channels[req.ResourceARN]
+		found = ch != nil
+		if found {
+			keys := []string{}
+			for k := range ch.tags {
+				keys = append(keys, k)
+			}
+			tagKeys, next := page(keys, req.NextToken)
+			tags := map[string]string{}
+			for _, k := range tagKeys {
+				tags[k] = ch.tags[k]
+			}
+			output["Tags"] = tags
+			output["NextToken"] = next
+		}
+	}
+
+	// Missing channel
+	if !found {
+		fakeError(w, http.StatusNotFound, "ResourceNotFoundException", "The requested channel is not found or not active.")
+		return
+	}
+	_ = json.NewEncoder(w).Encode(output)
+}
+
+// New control plane client over a fake control plane
+func newFakeControlPlane(t *testing.T) (*channel.Client, func()) {
+	server := httptest.NewServer(&fakeControlPlane{channels: map[string]*fakeChannel{}})
+	return newClient(t, server), server.Close
+}
+
+// Testing signaling channel is created, updated and deleted
+func TestChannelLifecycle(t *testing.T) {
+	client, closeServer := newFakeControlPlane(t)
+	defer closeServer()
+	ctx := context.Background()
+
+	// Create channel
+	arn, err := client.CreateSignalingChannel(ctx, channel.CreateInput{ChannelName: channelName, MessageTTL: 30 * time.Second, Tags: map[string]string{"team": "video"}})
+	assert.NoError(t, err)
+	assert.Equal(t, channelARN, arn)
+
+	// Same name again
+	_, err = client.CreateSignalingChannel(ctx, channel.CreateInput{ChannelName: channelName})
+	assert.ErrorIs(t, err, channel.ErrChannelExists)
+
+	// Describe it
+	info, err := client.DescribeSignalingChannel(ctx, channelName)
+	assert.NoError(t, err)
+	assert.Equal(t, channel.TypeSingleMaster, info.ChannelType)
+	assert.Equal(t, 30*time.Second, info.MessageTTL)
+
+	// Update with current version and then with old version
+	assert.NoError(t, client.UpdateSignalingChannel(ctx, arn, info.Version, 2*time.Minute))
+	assert.ErrorIs(t, client.UpdateSignalingChannel(ctx, arn, info.Version, time.Minute), channel.ErrVersionMismatch)
+	info, err = client.DescribeSignalingChannel(ctx, arn)
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, info.MessageTTL)
+
+	// Delete it
+	assert.NoError(t, client.DeleteSignalingChannel(ctx, arn, info.Version))
+	_, err = client.DescribeSignalingChannel(ctx, arn)
+	assert.ErrorIs(t, err, channel.ErrChannelNotFound)
+	assert.ErrorIs(t, client.DeleteSignalingChannel(ctx, arn, ""), channel.ErrChannelNotFound)
+}
+
+// Testing signaling channels are listed page by page
+func TestListSignalingChannels(t *testing.T) {
+	client, closeServer := newFakeControlPlane(t)
+	defer closeServer()
+	ctx := context.Background()
+
+	// Five cameras and a doorbell
+	for _, name := range []string{"camera-1", "camera-2", "camera-3", "camera-4", "camera-5", "doorbell"} {
+		_, err := client.CreateSignalingChannel(ctx, channel.CreateInput{ChannelName: name})
+		assert.NoError(t, err)
+	}
+
+	// First page only
+	firstPage, nextToken, err := client.ListSignalingChannels(ctx, channel.ListInput{NamePrefix: "camera"})
+	assert.NoError(t, err)
+	assert.Len(t, firstPage, 2)
+	assert.NotEmpty(t, nextToken)
+
+	// Every page
+	channels, err := client.ListAllSignalingChannels(ctx, "camera")
+	assert.NoError(t, err)
+
+	// ASSERTS
+	names := []string{}
+	for _, info := range channels {
+		names = append(names, info.ChannelName)
+	}
+	assert.Equal(t, []string{"camera-1", "camera-2", "camera-3", "camera-4", "camera-5"}, names)
+}
+
+// Testing signaling channel tags
+func TestChannelTags(t *testing.T) {
+	client, closeServer := newFakeControlPlane(t)
+	defer closeServer()
+	ctx := context.Background()
+
+	// Channel with a tag
+	arn, err := client.CreateSignalingChannel(ctx, channel.CreateInput{ChannelName: channelName, Tags: map[string]string{"team": "video"}})
+	assert.NoError(t, err)
+
+	// Add and overwrite tags
+	assert.NoError(t, client.TagResource(ctx, arn, map[string]string{"team": "webrtc", "env": "test", "owner": "ops"}))
+	tags, err := client.ListTagsForResource(ctx, arn)
+
+	// ASSERTS
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "webrtc", "env": "test", "owner": "ops"}, tags)
+	assert.ErrorIs(t, client.TagResource(ctx, "arn:aws:kinesisvideo:us-west-2:123456789012:channel/missing/1", tags), channel.ErrChannelNotFound)
+}
@@ -0,0 +1,500 @@
+package peer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/channel"
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
+	"github.com/pion/webrtc/v4"
+)
+
+// Default wait for signaling channel ICE servers of a new peer connection, cached ones are used after it
+const DefaultIceServersTimeout = 5 * time.Second
+
+// Peer errors, match them with errors.Is
+var (
+	// Ice candidate received from a remote client without peer connection
+	ErrNoPeerConnection = errors.New("there is no peer connection for remote client")
+)
+
+// WebRTC peer over a signaling channel. Master peers answer offers of viewers, with a peer connection
+// per viewer, and viewer peers send an offer to master when they are opened
+type Peer struct {
+	role                    signaling.Role                                                // Master or viewer actor
+	signaling               *signaling.Client                                             // Signaling client owned by peer
+	signalingOptions        []func(*signaling.Client)                                     // Signaling client optional parameters
+	api                     *webrtc.API                                                   // Pion API for new peer connections
+	configuration           webrtc.Configuration                                          // Configuration for new peer connections
+	iceServers              *channel.IceServers                                           // Signaling channel ICE servers, nil to use configuration ones
+	iceServersTimeout       time.Duration                                                 // Wait for signaling channel ICE servers
+	onPeerConnection        func(pc *webrtc.PeerConnection, remoteClientID string) error  // Function for Peer Connection Event
+	onConnectionStateChange func(remoteClientID string, state webrtc.PeerConnectionState) // Function for Connection State Change Event
+	onError                 func(err error)                                               // Function for Error Event
+	connections             map[string]*connection                                        // Peer connections by remote client id
+	queues                  map[string][]func()                                           // Pending signaling messages by remote client id, handled in order
+	closed                  bool                                                          // Close was called, pending signaling messages are dropped
+	mu                      sync.Mutex                                                    // Protect event functions, connections and queues
+}
+
+// Peer connection with a remote client
+type connection struct {
+	pc                *webrtc.PeerConnection    // Pion peer connection
+	pendingCandidates []webrtc.ICECandidateInit // Remote candidates received before remote description
+}
+
+// Optional parameters
+
+// Use own signaling client optional parameters, like signer or reconnect policy
+func WithSignalingOptions(options ...func(*signaling.Client)) func(*Peer) {
+	return func(p *Peer) {
+		p.signalingOptions = append(p.signalingOptions, options...)
+	}
+}
+
+// Use own pion API, with own media engine, interceptors or setting engine
+func WithAPI(api *webrtc.API) func(*Peer) {
+	return func(p *Peer) {
+		p.api = api
+	}
+}
+
+// Use own peer connection configuration, like ICE servers
+func WithConfiguration(configuration webrtc.Configuration) func(*Peer) {
+	return func(p *Peer) {
+		p.configuration = configuration
+	}
+}
+
+// Use signaling channel ICE servers for new peer connections, instead of configuration ones.
+// They are refreshed when signaling service asks for it
+func WithIceServers(iceServers *channel.IceServers) func(*Peer) {
+	return func(p *Peer) {
+		p.iceServers = iceServers
+	}
+}
+
+// Use own wait for signaling channel ICE servers of a new peer connection, cached ones are used after it
+func WithIceServersTimeout(timeout time.Duration) func(*Peer) {
+	return func(p *Peer) {
+		p.iceServersTimeout = timeout
+	}
+}
+
+// New master peer, signaling config role is set to master
+func NewMaster(config *signaling.Config, options ...func(*Peer)) (*Peer, error) {
+	return newPeer(signaling.Master, config, options...)
+}
+
+// New viewer peer, signaling config role is set to viewer
+func NewViewer(config *signaling.Config, options ...func(*Peer)) (*Peer, error) {
+	return newPeer(signaling.Viewer, config, options...)
+}
+
+// New peer with own signaling client
+func newPeer(role signaling.Role, config *signaling.Config, options ...func(*Peer)) (*Peer, error) {
+	// Config must never be nil
+	if config == nil {
+		return nil, &signaling.ConfigError{Field: "Config", Reason: "cannot be nil"}
+	}
+
+	// New Peer with initial values
+	p := &Peer{
+		role:              role,
+		iceServersTimeout: DefaultIceServersTimeout,
+		connections:       make(map[string]*connection),
+		queues:            make(map[string][]func()),
+	}
+
+	// Getting other optional parameters
+	for _, o := range options {
+		o(p)
+	}
+
+	// If you are not using our API
+	if p.api == nil {
+		p.api = webrtc.NewAPI()
+	}
+
+	// New signaling client with peer role
+	signalingConfig := *config
+	signalingConfig.Role = role
+	client, err := signaling.New(&signalingConfig, p.signalingOptions...)
+	if err != nil {
+		return nil, err
+	}
+	p.signaling = client
+
+	// Bind signaling events to peer
+	client.OnError(p.emitError)
+	if p.iceServers != nil {
+		client.OnReconnectIceServer(p.iceServers.OnReconnectIceServer)
+	}
+	if role == signaling.Master {
+		// Offers may wait for ICE servers, so messages of every viewer are handled in order on their own goroutine
+		client.OnOffer(func(desc signaling.SessionDescription, from string) {
+			p.enqueue(p.remoteClientID(from), func() { p.handleOffer(desc, from) })
+		})
+		client.OnCandidate(func(candidate signaling.IceCandidateInit, from string) {
+			p.enqueue(p.remoteClientID(from), func() { p.handleCandidate(candidate, from) })
+		})
+	} else {
+		client.OnAnswer(p.handleAnswer)
+		client.OnCandidate(p.handleCandidate)
+	}
+
+	return p, nil
+}
+
+// On Peer Connection Event Function, triggered for every new peer connection before negotiation,
+// add tracks and data channels in it. Negotiation is aborted when it returns an error
+func (p *Peer) OnPeerConnection(f func(pc *webrtc.PeerConnection, remoteClientID string) error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onPeerConnection = f
+}
+
+// On Connection State Change Event Function
+func (p *Peer) OnConnectionStateChange(f func(remoteClientID string, state webrtc.PeerConnectionState)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onConnectionStateChange = f
+}
+
+// OnError Event Function, it receives signaling errors too
+func (p *Peer) OnError(f func(err error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onError = f
+}
+
+// Signaling returns signaling client owned by peer, use it for other signaling events
+func (p *Peer) Signaling() *signaling.Client {
+	return p.signaling
+}
+
+// PeerConnection returns peer connection with remote client, nil if it does not exist.
+// Viewer peers have a single peer connection with signaling.DefaultClientID
+func (p *Peer) PeerConnection(remoteClientID string) *webrtc.PeerConnection {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if conn := p.connections[remoteClientID]; conn != nil {
+		return conn.pc
+	}
+	return nil
+}
+
+// RemoteClientIDs returns remote clients with a peer connection
+func (p *Peer) RemoteClientIDs() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ids := make([]string, 0, len(p.connections))
+	for id := range p.connections {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Open signaling client and wait until it is OPEN, viewer peers send their offer too
+func (p *Peer) Open(ctx context.Context) error {
+	if err := p.signaling.OpenContext(ctx); err != nil {
+		return err
+	}
+
+	// Viewer starts negotiation
+	if p.role == signaling.Viewer {
+		return p.offer(ctx)
+	}
+	return nil
+}
+
+// Close every peer connection and signaling client
+func (p *Peer) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	connections := p.connections
+	p.connections = make(map[string]*connection)
+	p.mu.Unlock()
+
+	var errs []error
+	for _, conn := range connections {
+		if err := conn.pc.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	p.signaling.Close()
+	return errors.Join(errs...)
+}
+
+// Create peer connection with master and send offer
+func (p *Peer) offer(ctx context.Context) error {
+	conn, err := p.newConnection(signaling.DefaultClientID)
+	if err != nil {
+		return err
+	}
+
+	offer, err := conn.pc.CreateOffer(nil)
+	if err != nil {
+		return err
+	}
+	if err = conn.pc.SetLocalDescription(offer); err != nil {
+		return err
+	}
+
+	return p.signaling.SendOffer(ctx, signaling.SessionDescription{Type: signaling.SDPTypeOffer, SDP: offer.SDP}, "")
+}
+
+// Offer from a viewer, a new peer connection answers it
+func (p *Peer) handleOffer(desc signaling.SessionDescription, from string) {
+	// A new offer from same viewer replaces its peer connection
+	conn, err := p.newConnection(p.remoteClientID(from))
+	if err != nil {
+		p.emitError(err)
+		return
+	}
+
+	if err = p.setRemoteDescription(conn, webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: desc.SDP}); err != nil {
+		p.emitError(err)
+		return
+	}
+
+	answer, err := conn.pc.CreateAnswer(nil)
+	if err != nil {
+		p.emitError(err)
+		return
+	}
+	if err = conn.pc.SetLocalDescription(answer); err != nil {
+		p.emitError(err)
+		return
+	}
+
+	// Send errors are reported by signaling Error Event
+	_ = p.signaling.SendAnswer(context.Background(), signaling.SessionDescription{Type: signaling.SDPTypeAnswer, SDP: answer.SDP}, from)
+}
+
+// Answer from master
+func (p *Peer) handleAnswer(desc signaling.SessionDescription, from string) {
+	p.mu.Lock()
+	conn := p.connections[signaling.DefaultClientID]
+	p.mu.Unlock()
+
+	// Answer without offer
+	if conn == nil {
+		p.emitError(ErrNoPeerConnection)
+		return
+	}
+
+	if err := p.setRemoteDescription(conn, webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer, SDP: desc.SDP}); err != nil {
+		p.emitError(err)
+	}
+}
+
+// Remote ice candidate, queued until peer connection has remote description
+func (p *Peer) handleCandidate(candidate signaling.IceCandidateInit, from string) {
+	p.mu.Lock()
+	conn := p.connections[p.remoteClientID(from)]
+	if conn == nil {
+		p.mu.Unlock()
+		p.emitError(ErrNoPeerConnection)
+		return
+	}
+	if conn.pc.RemoteDescription() == nil {
+		conn.pendingCandidates = append(conn.pendingCandidates, webrtc.ICECandidateInit(candidate))
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+
+	if err := conn.pc.AddICECandidate(webrtc.ICECandidateInit(candidate)); err != nil {
+		p.emitError(err)
+	}
+}
+
+// Handle signaling message of a remote client after its previous ones, on a goroutine per remote client
+func (p *Peer) enqueue(remoteClientID string, f func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+
+	// Goroutine of remote client is already running
+	pending, running := p.queues[remoteClientID]
+	p.queues[remoteClientID] = append(pending, f)
+	if !running {
+		go p.drain(remoteClientID)
+	}
+}
+
+// Handle queued signaling messages of a remote client until there are none
+func (p *Peer) drain(remoteClientID string) {
+	for {
+		p.mu.Lock()
+		pending := p.queues[remoteClientID]
+		if len(pending) == 0 || p.closed {
+			delete(p.queues, remoteClientID)
+			p.mu.Unlock()
+			return
+		}
+		f := pending[0]
+		p.queues[remoteClientID] = pending[1:]
+		p.mu.Unlock()
+
+		f()
+	}
+}
+
+// Set remote description and add queued remote ice candidates
+func (p *Peer) setRemoteDescription(conn *connection, desc webrtc.SessionDescription) error {
+	if err := conn.pc.SetRemoteDescription(desc); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	pendingCandidates := conn.pendingCandidates
+	conn.pendingCandidates = nil
+	p.mu.Unlock()
+
+	for _, candidate := range pendingCandidates {
+		if err := conn.pc.AddICECandidate(candidate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// New peer connection with remote client, it replaces the existing one
+func (p *Peer) newConnection(remoteClientID string) (*connection, error) {
+	pc, err := p.api.NewPeerConnection(p.peerConfiguration())
+	if err != nil {
+		return nil, err
+	}
+	conn := &connection{pc: pc}
+
+	// Trickle local ice candidates, send errors are reported by signaling Error Event
+	pc.OnICECandidate(func(candidate *webrtc.ICECandidate) {
+		// Gathering finished
+		if candidate == nil {
+			return
+		}
+		_ = p.signaling.SendCandidate(context.Background(), signaling.IceCandidateInit(candidate.ToJSON()), p.recipientClientID(remoteClientID))
+	})
+
+	// Keep viewer session up to date and forget peer connections that are over
+	pc.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		p.emitConnectionStateChange(remoteClientID, state)
+		switch state {
+		case webrtc.PeerConnectionStateConnected:
+			p.signaling.MarkConnected(remoteClientID)
+		case webrtc.PeerConnectionStateFailed, webrtc.PeerConnectionStateClosed:
+			if p.removeConnection(remoteClientID, pc) {
+				p.signaling.EndSession(remoteClientID)
+			}
+		}
+	})
+
+	// Tracks and data channels
+	p.mu.Lock()
+	onPeerConnection := p.onPeerConnection
+	p.mu.Unlock()
+	if onPeerConnection != nil {
+		if err := onPeerConnection(pc, remoteClientID); err != nil {
+			_ = pc.Close()
+			return nil, err
+		}
+	}
+
+	p.mu.Lock()
+	old := p.connections[remoteClientID]
+	p.connections[remoteClientID] = conn
+	p.mu.Unlock()
+
+	if old != nil {
+		_ = old.pc.Close()
+	}
+	return conn, nil
+}
+
+// Configuration for a new peer connection with current signaling channel ICE servers. It delays
+// negotiation, so it waits for them a limited time and uses cached ones on failure
+func (p *Peer) peerConfiguration() webrtc.Configuration {
+	configuration := p.configuration
+	if p.iceServers == nil {
+		return configuration
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.iceServersTimeout)
+	defer cancel()
+	servers, err := p.iceServers.Get(ctx)
+
+	// if something wrong happened, cached servers may still work and STUN server always does
+	if err != nil {
+		p.emitError(err)
+		servers = p.iceServers.Cached()
+	}
+	configuration.ICEServers = make([]webrtc.ICEServer, 0, len(servers))
+	for _, server := range servers {
+		iceServer := webrtc.ICEServer{URLs: server.URLs, Username: server.Username}
+		// STUN servers have no credential
+		if server.Credential != "" {
+			iceServer.Credential = server.Credential
+		}
+		configuration.ICEServers = append(configuration.ICEServers, iceServer)
+	}
+	return configuration
+}
+
+// Remove peer connection if it was not replaced, return true if it was removed
+func (p *Peer) removeConnection(remoteClientID string, pc *webrtc.PeerConnection) bool {
+	p.mu.Lock()
+	conn := p.connections[remoteClientID]
+	removed := conn != nil && conn.pc == pc
+	if removed {
+		delete(p.connections, remoteClientID)
+	}
+	p.mu.Unlock()
+
+	_ = pc.Close()
+	return removed
+}
+
+// Remote client id of a signaling message sender, messages from master have no sender
+func (p *Peer) remoteClientID(from string) string {
+	if p.role == signaling.Viewer || from == "" {
+		return signaling.DefaultClientID
+	}
+	return from
+}
+
+// Recipient of a signaling message, viewers can not send messages with recipient
+func (p *Peer) recipientClientID(remoteClientID string) string {
+	if p.role == signaling.Viewer {
+		return ""
+	}
+	return remoteClientID
+}
+
+// Trigger Error Event if there is a function for it
+func (p *Peer) emitError(err error) {
+	p.mu.Lock()
+	f := p.onError
+	p.mu.Unlock()
+	if f != nil {
+		f(err)
+	}
+}
+
+// Trigger Connection State Change Event if there is a function for it
+func (p *Peer) emitConnectionStateChange(remoteClientID string, state webrtc.PeerConnectionState) {
+	p.mu.Lock()
+	f := p.onConnectionStateChange
+	p.mu.Unlock()
+	if f != nil {
+		f(remoteClientID, state)
+	}
+}
@@ -0,0 +1,380 @@
+package peer_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/channel"
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/peer"
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// Testing values
+var (
+	channelARN = "arn:aws:kinesisvideo:us-west-2:123456789012:channel/testChannel/1234567890"
+	clientID   = "TestClientId"
+	REGION     = "us-west-2"
+	ENDPOINT   = "wss://endpoint.kinesisvideo.amazonaws.com"
+)
+
+// Signer without signature
+type fakeSigner struct{}
+
+// Return endpoint as it is
+func (s *fakeSigner) GetSignedURL(endpoint string, queryParams signer.QueryParams, date *time.Time) (string, error) {
+	return endpoint, nil
+}
+
+// In memory websocket delivering sent messages to other side, like signaling service does
+type bridgeSocket struct {
+	other    *bridgeSocket // Other side
+	senderID string        // Sender client id of messages from this side, empty for master
+	inbox    chan []byte   // Messages for this side
+	done     chan struct{} // Closed on Close
+	once     sync.Once     // Close only once
+	onOpen   func()
+	onClose  func()
+}
+
+// New connected websockets for master and viewer
+func newBridge(viewerClientID string) (*bridgeSocket, *bridgeSocket) {
+	master := &bridgeSocket{inbox: make(chan []byte, 100), done: make(chan struct{})}
+	viewer := &bridgeSocket{inbox: make(chan []byte, 100), done: make(chan struct{}), senderID: viewerClientID}
+	master.other = viewer
+	viewer.other = master
+	return master, viewer
+}
+
+// Bridge On Open Event Function
+func (b *bridgeSocket) OnOpen(f func()) {
+	b.onOpen = f
+}
+
+// Bridge On Close Event Function
+func (b *bridgeSocket) OnClose(f func()) {
+	b.onClose = f
+}
+
+// Bridge never fails
+func (b *bridgeSocket) OnError(f func(err error)) {}
+
+// Bridge has no url
+func (b *bridgeSocket) SetURL(url string) error {
+	return nil
+}
+
+// Deliver messages one by one after dial
+func (b *bridgeSocket) OnMessage(dial chan string, f func(messageType int, data []byte)) {
+	go func() {
+		<-dial
+		for {
+			select {
+			case data := <-b.inbox:
+				f(signaling.TextMessage, data)
+			case <-b.done:
+				return
+			}
+		}
+	}()
+}
+
+// Bridge is open at once
+func (b *bridgeSocket) Dial() error {
+	b.onOpen()
+	return nil
+}
+
+// Stop delivering messages
+func (b *bridgeSocket) Close() {
+	b.once.Do(func() {
+		close(b.done)
+		if b.onClose != nil {
+			b.onClose()
+		}
+	})
+}
+
+// Translate sent message to received message for other side
+func (b *bridgeSocket) Send(msgType int, data []byte) error {
+	var sent signaling.WebSocketSignalingMessageSend
+	if err := json.Unmarshal(data, &sent); err != nil {
+		return err
+	}
+	received, _ := json.Marshal(signaling.WebSocketSignalingMessageReceive{
+		MessageType:    sent.MessageType,
+		MessagePayload: sent.MessagePayload,
+		SenderClientID: b.senderID,
+	})
+	b.other.inbox <- received
+	return nil
+}
+
+// Pion API gathering loopback candidates only
+func loopbackAPI() *webrtc.API {
+	settingEngine := webrtc.SettingEngine{}
+	settingEngine.SetIncludeLoopbackCandidate(true)
+	settingEngine.SetInterfaceFilter(func(name string) bool { return strings.HasPrefix(name, "lo") })
+	settingEngine.SetNetworkTypes([]webrtc.NetworkType{webrtc.NetworkTypeUDP4})
+	return webrtc.NewAPI(webrtc.WithSettingEngine(settingEngine))
+}
+
+// Testing master and viewer exchange data over a data channel
+func TestMasterViewer(t *testing.T) {
+	masterSocket, viewerSocket := newBridge(clientID)
+
+	// Signaling configures
+	configMaster := signaling.Config{ChannelARN: &channelARN, Region: &REGION, ChannelEndpoint: &ENDPOINT}
+	configViewer := signaling.Config{ChannelARN: &channelARN, Region: &REGION, ChannelEndpoint: &ENDPOINT, ClientID: &clientID}
+
+	// New master
+	master, err := peer.NewMaster(&configMaster, peer.WithAPI(loopbackAPI()),
+		peer.WithSignalingOptions(signaling.WithSigner(&fakeSigner{}), signaling.WithWebsocketClient(masterSocket)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer master.Close()
+
+	// New viewer
+	viewer, err := peer.NewViewer(&configViewer, peer.WithAPI(loopbackAPI()),
+		peer.WithSignalingOptions(signaling.WithSigner(&fakeSigner{}), signaling.WithWebsocketClient(viewerSocket)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer viewer.Close()
+
+	// Master echoes data channel messages
+	master.OnPeerConnection(func(pc *webrtc.PeerConnection, remoteClientID string) error {
+		assert.Equal(t, clientID, remoteClientID)
+		pc.OnDataChannel(func(dc *webrtc.DataChannel) {
+			dc.OnMessage(func(msg webrtc.DataChannelMessage) {
+				_ = dc.SendText("echo " + string(msg.Data))
+			})
+		})
+		return nil
+	})
+
+	// Viewer opens a data channel
+	c := make(chan string, 1)
+	viewer.OnPeerConnection(func(pc *webrtc.PeerConnection, remoteClientID string) error {
+		assert.Equal(t, signaling.DefaultClientID, remoteClientID)
+		dc, err := pc.CreateDataChannel("test", nil)
+		if err != nil {
+			return err
+		}
+		dc.OnOpen(func() {
+			_ = dc.SendText("hello")
+		})
+		dc.OnMessage(func(msg webrtc.DataChannelMessage) {
+			c <- string(msg.Data)
+		})
+		return nil
+	})
+
+	// Unexpected errors
+	master.OnError(func(err error) {
+		t.Errorf("Unexpected master error: %v", err)
+	})
+	viewer.OnError(func(err error) {
+		t.Errorf("Unexpected viewer error: %v", err)
+	})
+
+	// Open master first, then viewer sends its offer
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	assert.NoError(t, master.Open(ctx))
+	assert.NoError(t, viewer.Open(ctx))
+
+	// ASSERTS
+	select {
+	case msg := <-c:
+		assert.Equal(t, "echo hello", msg)
+	case <-ctx.Done():
+		t.Fatalf("Data channel message not received")
+	}
+	assert.Equal(t, []string{clientID}, master.RemoteClientIDs())
+	assert.Eventually(t, func() bool {
+		sessions := master.Signaling().Sessions()
+		return len(sessions) == 1 && sessions[0].State == signaling.SessionConnected
+	}, time.Second, 10*time.Millisecond)
+	assert.NotNil(t, viewer.PeerConnection(signaling.DefaultClientID))
+}
+
+// Testing peer needs a config
+func TestNewMasterNilConfig(t *testing.T) {
+	_, err := peer.NewMaster(nil)
+	assert.ErrorIs(t, err, signaling.ErrInvalidConfig)
+}
+
+// Testing peer connections use signaling channel ICE servers
+func TestWithIceServers(t *testing.T) {
+	// Signaling channel HTTPS endpoint stand-in
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"IceServerList":[{"Password":"password","Ttl":300,"Uris":["turn:127.0.0.1:3478?transport=udp"],"Username":"username"}]}`))
+	}))
+	defer server.Close()
+
+	// ICE servers of viewer
+	configViewer := signaling.Config{ChannelARN: &channelARN, Region: &REGION, ChannelEndpoint: &ENDPOINT, HTTPSEndpoint: &server.URL, ClientID: &clientID}
+	client, _ := channel.New(REGION, channel.WithEndpoint(server.URL), channel.WithCredentialsValue(&credentials.Value{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}))
+	iceServers, err := channel.NewIceServers(client, &configViewer)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer iceServers.Close()
+
+	// New viewer
+	_, viewerSocket := newBridge(clientID)
+	viewer, err := peer.NewViewer(&configViewer, peer.WithAPI(loopbackAPI()), peer.WithIceServers(iceServers),
+		peer.WithSignalingOptions(signaling.WithSigner(&fakeSigner{}), signaling.WithWebsocketClient(viewerSocket)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer viewer.Close()
+
+	// Keep ICE servers of peer connection and abort negotiation
+	errAbort := errors.New("abort")
+	var servers []webrtc.ICEServer
+	viewer.OnPeerConnection(func(pc *webrtc.PeerConnection, remoteClientID string) error {
+		servers = pc.GetConfiguration().ICEServers
+		return errAbort
+	})
+
+	// ASSERTS
+	assert.ErrorIs(t, viewer.Open(context.Background()), errAbort)
+	assert.Equal(t, []webrtc.ICEServer{
+		{URLs: []string{"stun:stun.kinesisvideo.us-west-2.amazonaws.com:443"}},
+		{URLs: []string{"turn:127.0.0.1:3478?transport=udp"}, Username: "username", Credential: "password"},
+	}, servers)
+}
+
+// Testing peer connections use cached ICE servers when signaling channel ones do not arrive in time
+func TestWithIceServersTimeout(t *testing.T) {
+	// Signaling channel HTTPS endpoint stand-in that never answers in time
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	// ICE servers of viewer
+	configViewer := signaling.Config{ChannelARN: &channelARN, Region: &REGION, ChannelEndpoint: &ENDPOINT, HTTPSEndpoint: &server.URL, ClientID: &clientID}
+	client, _ := channel.New(REGION, channel.WithEndpoint(server.URL), channel.WithCredentialsValue(&credentials.Value{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}))
+	iceServers, err := channel.NewIceServers(client, &configViewer)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer iceServers.Close()
+
+	// New viewer waiting a short time for ICE servers
+	_, viewerSocket := newBridge(clientID)
+	viewer, err := peer.NewViewer(&configViewer, peer.WithAPI(loopbackAPI()), peer.WithIceServers(iceServers),
+		peer.WithIceServersTimeout(20*time.Millisecond),
+		peer.WithSignalingOptions(signaling.WithSigner(&fakeSigner{}), signaling.WithWebsocketClient(viewerSocket)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer viewer.Close()
+
+	// if error event
+	var receivedErr error
+	viewer.OnError(func(err error) {
+		receivedErr = err
+	})
+
+	// Keep ICE servers of peer connection and abort negotiation
+	errAbort := errors.New("abort")
+	var servers []webrtc.ICEServer
+	viewer.OnPeerConnection(func(pc *webrtc.PeerConnection, remoteClientID string) error {
+		servers = pc.GetConfiguration().ICEServers
+		return errAbort
+	})
+
+	// ASSERTS
+	assert.ErrorIs(t, viewer.Open(context.Background()), errAbort)
+	assert.ErrorIs(t, receivedErr, context.DeadlineExceeded)
+	assert.Equal(t, []webrtc.ICEServer{{URLs: []string{"stun:stun.kinesisvideo.us-west-2.amazonaws.com:443"}}}, servers)
+}
+
+// Signaling message with an offer of a new viewer, as signaling service delivers it to master
+func offerMessage(t *testing.T, from string) []byte {
+	pc, err := loopbackAPI().NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer pc.Close()
+	if _, err = pc.CreateDataChannel("test", nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	offer, err := pc.CreateOffer(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	payload, _ := json.Marshal(offer)
+	message, _ := json.Marshal(signaling.WebSocketSignalingMessageReceive{
+		MessageType:    "SDP_OFFER",
+		MessagePayload: base64.StdEncoding.EncodeToString(payload),
+		SenderClientID: from,
+	})
+	return message
+}
+
+// Testing a viewer whose peer connection is slow to set up does not delay answers to other viewers
+func TestMasterAnswersWhileOtherOfferWaits(t *testing.T) {
+	masterSocket, viewerSocket := newBridge(clientID)
+
+	// New master
+	configMaster := signaling.Config{ChannelARN: &channelARN, Region: &REGION, ChannelEndpoint: &ENDPOINT}
+	master, err := peer.NewMaster(&configMaster, peer.WithAPI(loopbackAPI()),
+		peer.WithSignalingOptions(signaling.WithSigner(&fakeSigner{}), signaling.WithWebsocketClient(masterSocket)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer master.Close()
+
+	// Peer connection of slow viewer waits until test releases it, like a wait for ICE servers
+	release := make(chan struct{})
+	defer close(release)
+	master.OnPeerConnection(func(pc *webrtc.PeerConnection, remoteClientID string) error {
+		if remoteClientID == "slow" {
+			<-release
+		}
+		return nil
+	})
+
+	// Open master, then both viewers send their offers
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, master.Open(ctx))
+	masterSocket.inbox <- offerMessage(t, "slow")
+	masterSocket.inbox <- offerMessage(t, "fast")
+
+	// ASSERTS, answer of fast viewer is sent while slow one waits
+	for {
+		select {
+		case data := <-viewerSocket.inbox:
+			var message signaling.WebSocketSignalingMessageReceive
+			assert.NoError(t, json.Unmarshal(data, &message))
+			if message.MessageType == "SDP_ANSWER" {
+				assert.NotNil(t, master.PeerConnection("fast"))
+				assert.Nil(t, master.PeerConnection("slow"))
+				return
+			}
+		case <-ctx.Done():
+			t.Fatalf("Answer not sent")
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package signaling
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Default max ice candidates queued for a client before its sdp message
+const DefaultMaxPendingIceCandidates = 50
+
+// Default time an ice candidate can be queued before its sdp message
+const DefaultPendingIceCandidateTTL = 30 * time.Second
+
+// Ice candidate waiting for sdp message of its sender
+type pendingIceCandidate struct {
+	iceCandidate string    // Ice candidate message payload
+	ufrag        string    // ICE username fragment of its negotiation, empty if it has none
+	queuedAt     time.Time // When it was queued
+}
+
+// Ice candidate dropped from queue
+type droppedIceCandidate struct {
+	iceCandidate string // Ice candidate message payload
+	clientID     string // Sender client id
+	reason       error  // Why it was dropped
+}
+
+// Use own limits for ice candidates queued before sdp message of their sender, max candidates per
+// client and time they can be queued. 0 means unlimited
+func WithPendingIceCandidates(maxPerClient int, ttl time.Duration) func(*Client) {
+	return func(sc *Client) {
+		sc.maxPendingIceCandidates = maxPerClient
+		sc.pendingIceCandidateTTL = ttl
+	}
+}
+
+// On Ice Candidate Dropped Event Function, triggered when a queued ice candidate is dropped
+// because its client queue is full, it expired or it belongs to a previous negotiation
+func (sc *Client) OnIceCandidateDropped(f func(iceCandidate string, clientID string, reason error)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.onIceCandidateDropped = f
+}
+
+// DroppedIceCandidates returns how many queued ice candidates were dropped
+func (sc *Client) DroppedIceCandidates() uint64 {
+	return sc.droppedIceCandidates.Load()
+}
+
+// Queue ice candidate of a client, lock must be held by caller. It returns dropped ice candidates
+func (sc *Client) queueIceCandidateLocked(clientID string, iceCandidate string, ufrag string, now time.Time) []droppedIceCandidate {
+	// Expired candidates of every client, so queues of clients that never send sdp are freed too
+	dropped := sc.evictExpiredIceCandidatesLocked(now)
+
+	// Client queue is full, newest candidate is dropped
+	if sc.maxPendingIceCandidates > 0 && len(sc.pendingIceCandidatesByClientID[clientID]) >= sc.maxPendingIceCandidates {
+		return append(dropped, droppedIceCandidate{iceCandidate: iceCandidate, clientID: clientID, reason: ErrIceCandidateQueueFull})
+	}
+
+	sc.pendingIceCandidatesByClientID[clientID] = append(sc.pendingIceCandidatesByClientID[clientID],
+		pendingIceCandidate{iceCandidate: iceCandidate, ufrag: ufrag, queuedAt: now})
+	return dropped
+}
+
+// Remove expired ice candidates from every client queue, lock must be held by caller
+func (sc *Client) evictExpiredIceCandidatesLocked(now time.Time) []droppedIceCandidate {
+	if sc.pendingIceCandidateTTL <= 0 {
+		return nil
+	}
+
+	var dropped []droppedIceCandidate
+	for clientID, queue := range sc.pendingIceCandidatesByClientID {
+		// Queues are sorted by time, so only the head can be expired
+		expired := 0
+		for expired < len(queue) && now.Sub(queue[expired].queuedAt) >= sc.pendingIceCandidateTTL {
+			dropped = append(dropped, droppedIceCandidate{iceCandidate: queue[expired].iceCandidate, clientID: clientID, reason: ErrIceCandidateExpired})
+			expired++
+		}
+		if expired == len(queue) {
+			delete(sc.pendingIceCandidatesByClientID, clientID)
+		} else if expired > 0 {
+			sc.pendingIceCandidatesByClientID[clientID] = queue[expired:]
+		}
+	}
+	return dropped
+}
+
+// Count dropped ice candidates and trigger their events
+func (sc *Client) dropIceCandidates(dropped []droppedIceCandidate) {
+	for _, d := range dropped {
+		sc.droppedIceCandidates.Add(1)
+		sc.emitIceCandidateDropped(d.iceCandidate, d.clientID, d.reason)
+	}
+}
+
+// Check if ice candidate belongs to current negotiation of its client, lock must be held by caller.
+// Candidates or offers without username fragment belong to any negotiation
+func (sc *Client) isCurrentNegotiationLocked(clientID string, ufrag string) bool {
+	remoteUfrag := sc.remoteUfragByClientID[clientID]
+	return ufrag == "" || remoteUfrag == "" || ufrag == remoteUfrag
+}
+
+// ICE username fragment of a sdp message payload, empty if it has none
+func sdpUfrag(payload string) string {
+	var desc SessionDescription
+	if err := json.Unmarshal([]byte(payload), &desc); err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(desc.SDP, "\n") {
+		if ufrag, found := strings.CutPrefix(strings.TrimSpace(line), "a=ice-ufrag:"); found {
+			return ufrag
+		}
+	}
+	return ""
+}
+
+// ICE username fragment of an ice candidate message payload, empty if it has none
+func candidateUfrag(payload string) string {
+	var candidate IceCandidateInit
+	if err := json.Unmarshal([]byte(payload), &candidate); err != nil {
+		return ""
+	}
+	if candidate.UsernameFragment != nil {
+		return *candidate.UsernameFragment
+	}
+
+	// Candidate attribute may carry it as extension
+	fields := strings.Fields(candidate.Candidate)
+	for i := 0; i+1 < len(fields); i++ {
+		if fields[i] == "ufrag" {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,157 @@
+package signaling_test
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
+	"github.com/stretchr/testify/assert"
+)
+
+// Testing ice candidates over client queue limit are dropped
+func TestPendingIceCandidatesQueueFull(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client queuing a single candidate per viewer
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket, signaling.WithPendingIceCandidates(1, time.Minute))
+
+	// if ice candidate dropped event
+	reasons := []error{}
+	client.OnIceCandidateDropped(func(iceCandidate string, clientID string, reason error) {
+		assert.Equal(t, ICECandidate, iceCandidate)
+		assert.Equal(t, "TestClientId", clientID)
+		reasons = append(reasons, reason)
+	})
+
+	// Count delivered candidates
+	candidates := 0
+	client.OnIceCandidate(func(iceCandidate *string, clientID *string) {
+		candidates++
+	})
+	client.OnSdpOffer(func(offer *string, remoteClientID *string) {})
+
+	// Candidates before offer are queued
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(iceCandidateViewerMessage))
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(iceCandidateViewerMessage))
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(sdpOfferViewerMessage))
+
+	// ASSERTS
+	assert.Equal(t, 1, candidates)
+	assert.Equal(t, []error{signaling.ErrIceCandidateQueueFull}, reasons)
+	assert.Equal(t, uint64(1), client.DroppedIceCandidates())
+}
+
+// Testing expired ice candidates are not delivered
+func TestPendingIceCandidatesExpired(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// New client with short ice candidate ttl and event stream
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket, signaling.WithPendingIceCandidates(0, 10*time.Millisecond))
+	events := client.Events()
+
+	// Ice Candidate Event must not be triggered
+	client.OnIceCandidate(func(iceCandidate *string, clientID *string) {
+		t.Errorf("Unexpected ice candidate")
+	})
+	client.OnSdpOffer(func(offer *string, remoteClientID *string) {})
+
+	// Candidate expires before offer
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(iceCandidateViewerMessage))
+	time.Sleep(20 * time.Millisecond)
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(sdpOfferViewerMessage))
+
+	// ASSERTS
+	assert.Equal(t, signaling.SdpOfferEvent{Offer: SDPOffer, ClientID: clientID}, nextEvent(t, events))
+	assert.Equal(t, signaling.IceCandidateDroppedEvent{IceCandidate: ICECandidate, ClientID: clientID, Reason: signaling.ErrIceCandidateExpired}, nextEvent(t, events))
+	assert.Equal(t, uint64(1), client.DroppedIceCandidates())
+}
+
+// Testing a new offer from same viewer queues its ice candidates again
+func TestPendingIceCandidatesNewOffer(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket)
+
+	// Keep order of offers and candidates
+	received := []string{}
+	client.OnSdpOffer(func(offer *string, remoteClientID *string) {
+		received = append(received, "offer")
+	})
+	client.OnIceCandidate(func(iceCandidate *string, clientID *string) {
+		received = append(received, "candidate")
+	})
+
+	// First negotiation
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(sdpOfferViewerMessage))
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(iceCandidateViewerMessage))
+
+	// Viewer starts over, candidate of new negotiation is delivered after new offer
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(sdpOfferViewerMessage))
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(iceCandidateViewerMessage))
+
+	// ASSERTS
+	assert.Equal(t, []string{"offer", "candidate", "offer", "candidate"}, received)
+	assert.Equal(t, uint64(0), client.DroppedIceCandidates())
+}
+
+// Message from test viewer with input type and json payload
+func viewerMessage(messageType string, payload string) []byte {
+	return []byte(`{"messageType":"` + messageType + `","messagePayload":"` + base64.StdEncoding.EncodeToString([]byte(payload)) +
+		`","senderClientId":"TestClientId"}`)
+}
+
+// Testing a restarting viewer gets candidates of its new negotiation only after its new offer
+func TestPendingIceCandidatesRestart(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket)
+
+	// if ice candidate dropped event
+	dropped := []string{}
+	client.OnIceCandidateDropped(func(iceCandidate string, clientID string, reason error) {
+		assert.ErrorIs(t, reason, signaling.ErrIceCandidateStale)
+		dropped = append(dropped, iceCandidate)
+	})
+
+	// Keep order of offers and candidates
+	received := []string{}
+	client.OnSdpOffer(func(offer *string, remoteClientID *string) {
+		received = append(received, "offer")
+	})
+	client.OnIceCandidate(func(iceCandidate *string, clientID *string) {
+		received = append(received, *iceCandidate)
+	})
+
+	// Offers and candidates of every negotiation
+	offer := func(ufrag string) []byte {
+		return viewerMessage("SDP_OFFER", `{"type":"offer","sdp":"v=0\r\na=ice-ufrag:`+ufrag+`\r\na=ice-pwd:pwd\r\n"}`)
+	}
+	candidate := func(ufrag string) string {
+		return `{"candidate":"candidate:1 1 udp 1 10.0.0.1 5000 typ host ufrag ` + ufrag + `","sdpMid":"0"}`
+	}
+
+	// First negotiation
+	ownMockWebsocket.onMessage(signaling.TextMessage, offer("first"))
+	ownMockWebsocket.onMessage(signaling.TextMessage, viewerMessage("ICE_CANDIDATE", candidate("first")))
+
+	// Candidates of an abandoned negotiation and of the new one arrive before new offer
+	ownMockWebsocket.onMessage(signaling.TextMessage, viewerMessage("ICE_CANDIDATE", candidate("abandoned")))
+	ownMockWebsocket.onMessage(signaling.TextMessage, viewerMessage("ICE_CANDIDATE", candidate("second")))
+	ownMockWebsocket.onMessage(signaling.TextMessage, offer("second"))
+
+	// ASSERTS
+	assert.Equal(t, []string{"offer", candidate("first"), "offer", candidate("second")}, received)
+	assert.Equal(t, []string{candidate("abandoned")}, dropped)
+	assert.Equal(t, uint64(1), client.DroppedIceCandidates())
+}
@@ -0,0 +1,95 @@
+package signaling
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Smallest clock offset change worth a new connection attempt, server dates have second precision
+const minClockSkewCorrection = time.Second
+
+// Messages of signatures rejected because signing time is too far from AWS clock
+var clockSkewMessages = []string{
+	"Signature expired",
+	"Signature not yet current",
+	"RequestTimeTooSkewed",
+	"RequestExpired",
+}
+
+// Use own source of AWS clock, it is asked when a handshake is rejected because of clock skew
+// and response has no Date header, like channel.Client ServerTime
+func WithServerTime(serverTime func(ctx context.Context) (time.Time, error)) func(*Client) {
+	return func(sc *Client) {
+		sc.serverTime = serverTime
+	}
+}
+
+// On Clock Skew Corrected Event Function, triggered when signing clock offset changes after a
+// handshake rejected because of clock skew. Offset is AWS clock minus local clock
+func (sc *Client) OnClockSkewCorrected(f func(offset time.Duration)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.onClockSkewCorrected = f
+}
+
+// ClockOffset returns offset applied to local clock when signing, AWS clock minus local clock
+func (sc *Client) ClockOffset() time.Duration {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.dateProvider.TotalOffset()
+}
+
+// Update signing clock offset after a clock skew error, return true if it changed so a
+// new connection attempt may succeed
+func (sc *Client) correctClockSkew(ctx context.Context, err error) bool {
+	var skewErr *ClockSkewError
+	if !errors.As(err, &skewErr) {
+		return false
+	}
+
+	// Response without date, ask own AWS clock source
+	serverTime := skewErr.ServerTime
+	if serverTime.IsZero() && sc.serverTime != nil {
+		var timeErr error
+		if serverTime, timeErr = sc.serverTime(ctx); timeErr != nil {
+			return false
+		}
+	}
+	if serverTime.IsZero() {
+		return false
+	}
+
+	offset := time.Until(serverTime)
+	sc.mu.Lock()
+	change := offset - sc.dateProvider.TotalOffset()
+	changed := change >= minClockSkewCorrection || change <= -minClockSkewCorrection
+	if changed {
+		// Corrected offset replaces both offsets
+		sc.dateProvider.ClockOffset = 0
+		sc.dateProvider.Offset = offset
+	}
+	sc.mu.Unlock()
+
+	if changed {
+		sc.emitClockSkewCorrected(offset)
+	}
+	return changed
+}
+
+// Clock skew error for a handshake response rejecting signing time, input error otherwise
+func clockSkewError(handshakeErr *HandshakeError) error {
+	if handshakeErr.StatusCode < 400 || handshakeErr.StatusCode >= 500 {
+		return handshakeErr
+	}
+
+	for _, message := range clockSkewMessages {
+		if strings.Contains(string(handshakeErr.Body), message) {
+			serverTime, _ := http.ParseTime(handshakeErr.Header.Get("Date"))
+			return &ClockSkewError{ServerTime: serverTime, Err: handshakeErr}
+		}
+	}
+	return handshakeErr
+}
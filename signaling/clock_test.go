@@ -0,0 +1,164 @@
+package signaling_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// Signer adding signing date to endpoint, like a presigned url does
+type dateSigner struct{}
+
+// Return endpoint with X-Amz-Date
+func (s *dateSigner) GetSignedURL(endpoint string, queryParams signer.QueryParams, date *time.Time) (string, error) {
+	return endpoint + "?X-Amz-Date=" + date.UTC().Format("20060102T150405Z"), nil
+}
+
+// Signaling service stand-in with its own clock, it rejects signatures more than 5 minutes away from it
+func newSkewedService(t *testing.T, offset time.Duration, withDate bool) (*httptest.Server, func() int) {
+	var mu sync.Mutex
+	handshakes := 0
+	upgrader := websocket.Upgrader{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		handshakes++
+		mu.Unlock()
+
+		now := time.Now().Add(offset).UTC()
+		signed, err := time.Parse("20060102T150405Z", r.URL.Query().Get("X-Amz-Date"))
+		assert.NoError(t, err)
+
+		// Signature out of time
+		if signed.Before(now.Add(-5*time.Minute)) || signed.After(now.Add(5*time.Minute)) {
+			if withDate {
+				w.Header().Set("Date", now.Format(http.TimeFormat))
+			} else {
+				w.Header()["Date"] = nil
+			}
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"Signature expired: ` + signed.Format("20060102T150405Z") + ` is now earlier than ` +
+				now.Add(-5*time.Minute).Format("20060102T150405Z") + ` (` + now.Format("20060102T150405Z") + ` - 5 min.)"}`))
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+	}))
+
+	return server, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return handshakes
+	}
+}
+
+// Signaling client for a signaling service stand-in, it signs with dateSigner
+func newStandInClient(t *testing.T, server *httptest.Server, options ...func(*signaling.Client)) *signaling.Client {
+	endpoint := "ws" + strings.TrimPrefix(server.URL, "http")
+	configMaster.ChannelEndpoint = &endpoint
+	client, err := signaling.New(&configMaster, append(options, signaling.WithSigner(&dateSigner{}))...)
+
+	// if something wrong happened
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return client
+}
+
+// Testing clock offset is derived from handshake response date and connection is tried again
+func TestClockSkewFromHandshake(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Service clock is one hour ahead
+	server, handshakes := newSkewedService(t, time.Hour, true)
+	defer server.Close()
+	client := newStandInClient(t, server)
+	defer client.Close()
+
+	// if clock skew corrected event
+	var corrected time.Duration
+	client.OnClockSkewCorrected(func(offset time.Duration) {
+		corrected = offset
+	})
+
+	// Signaling Open Connection
+	err := client.OpenContext(context.Background())
+
+	// ASSERTS
+	assert.NoError(t, err)
+	assert.Equal(t, 2, handshakes())
+	assert.InDelta(t, float64(time.Hour), float64(corrected), float64(2*time.Second))
+	assert.Equal(t, corrected, client.ClockOffset())
+}
+
+// Testing clock offset is derived from own AWS clock source when handshake response has no date
+func TestClockSkewFromServerTime(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Service clock is one hour behind, without Date header
+	server, handshakes := newSkewedService(t, -time.Hour, false)
+	defer server.Close()
+	client := newStandInClient(t, server, signaling.WithServerTime(func(ctx context.Context) (time.Time, error) {
+		return time.Now().Add(-time.Hour), nil
+	}))
+	defer client.Close()
+	events := client.Events()
+
+	// Signaling Open Connection
+	err := client.OpenContext(context.Background())
+
+	// ASSERTS
+	assert.NoError(t, err)
+	assert.Equal(t, 2, handshakes())
+	errorEvent, ok := nextEvent(t, events).(signaling.ErrorEvent)
+	assert.True(t, ok)
+	assert.ErrorIs(t, errorEvent.Err, signaling.ErrClockSkew)
+	skewEvent, ok := nextEvent(t, events).(signaling.ClockSkewCorrectedEvent)
+	assert.True(t, ok)
+	assert.InDelta(t, float64(-time.Hour), float64(skewEvent.Offset), float64(time.Second))
+}
+
+// Testing clock skew without a way to know AWS clock is a dial error
+func TestClockSkewUncorrected(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Service clock is one hour ahead, without Date header
+	server, handshakes := newSkewedService(t, time.Hour, false)
+	defer server.Close()
+	client := newStandInClient(t, server)
+	defer client.Close()
+
+	// Signaling Open Connection
+	err := client.OpenContext(context.Background())
+
+	// ASSERTS
+	assert.ErrorIs(t, err, signaling.ErrClockSkew)
+	var transportErr *signaling.TransportError
+	assert.ErrorAs(t, err, &transportErr)
+	assert.True(t, signaling.IsRetryable(err))
+	assert.Equal(t, 1, handshakes())
+	assert.Equal(t, time.Duration(0), client.ClockOffset())
+}
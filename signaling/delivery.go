@@ -0,0 +1,187 @@
+package signaling
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultStatusResponseWindow is the wait for a status response before a tracked message is considered delivered
+const DefaultStatusResponseWindow = 5 * time.Second
+
+// Length of generated correlation ids
+const correlationIDLength = 16
+
+// Delivery of a tracked message. Signaling service only answers on failure, so a message
+// is delivered when no status response arrives for its correlation id within the status response window
+type Delivery struct {
+	CorrelationID string           // Correlation id sent with the message
+	done          chan struct{}    // Closed when delivery is resolved
+	once          sync.Once        // Resolve only once
+	err           error            // Delivery result
+	timer         *time.Timer      // Status response window timer
+	ws            WebSocketClientI // Connection the message was sent on, nil until it is sent
+}
+
+// New pending delivery
+func newDelivery(correlationID string) *Delivery {
+	return &Delivery{
+		CorrelationID: correlationID,
+		done:          make(chan struct{}),
+	}
+}
+
+// Resolve delivery with result
+func (d *Delivery) resolve(err error) {
+	d.once.Do(func() {
+		d.err = err
+		close(d.done)
+	})
+}
+
+// Done returns a channel closed when delivery is resolved
+func (d *Delivery) Done() <-chan struct{} {
+	return d.done
+}
+
+// Err returns delivery result, nil while it is pending or if it was delivered
+func (d *Delivery) Err() error {
+	select {
+	case <-d.done:
+		return d.err
+	default:
+		return nil
+	}
+}
+
+// Wait until delivery is resolved or context is done
+func (d *Delivery) Wait(ctx context.Context) error {
+	select {
+	case <-d.done:
+		return d.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Use own status response window for tracked messages
+func WithStatusResponseWindow(window time.Duration) func(*Client) {
+	return func(sc *Client) {
+		sc.statusResponseWindow = window
+	}
+}
+
+// Sender signaling Sdp Offer Messages with correlation id
+func (sc *Client) SendSdpOfferAsync(sdpOfferMsg string, recipientClientID *string) *Delivery {
+	return sc.sendTracked(sdpOffer, sdpOfferMsg, recipientClientID)
+}
+
+// Sender signaling Sdp Answer Messages with correlation id
+func (sc *Client) SendSdpAnswerAsync(sdpAnswerMsg string, recipientClientID *string) *Delivery {
+	return sc.sendTracked(sdpAnswer, sdpAnswerMsg, recipientClientID)
+}
+
+// Sender signaling Ice Candidate Messages with correlation id
+func (sc *Client) SendIceCandidateAsync(iceCandidateMsg string, recipientClientID *string) *Delivery {
+	return sc.sendTracked(iceCandidate, iceCandidateMsg, recipientClientID)
+}
+
+// Generic Sender signaling Messages with correlation id
+func (sc *Client) sendTracked(msgType MessageType, payload string, recipientClientID *string) *Delivery {
+	// Register delivery before sending, status response can be faster than us
+	delivery := newDelivery(RandSeq(correlationIDLength))
+	sc.mu.Lock()
+	sc.deliveries[delivery.CorrelationID] = delivery
+	sc.mu.Unlock()
+
+	// Send Message
+	if err := sc.sendMessage(context.Background(), msgType, payload, recipientClientIDValue(recipientClientID), delivery.CorrelationID); err != nil {
+		sc.takeDelivery(delivery.CorrelationID)
+		delivery.resolve(err)
+		return delivery
+	}
+
+	// No news is good news, unless it was already resolved by a status response
+	sc.mu.Lock()
+	if _, ok := sc.deliveries[delivery.CorrelationID]; ok {
+		delivery.timer = time.AfterFunc(sc.statusResponseWindow, func() {
+			if sc.takeDelivery(delivery.CorrelationID) != nil {
+				delivery.resolve(nil)
+			}
+		})
+	}
+	sc.mu.Unlock()
+
+	return delivery
+}
+
+// Remove pending delivery, nil if it does not exist
+func (sc *Client) takeDelivery(correlationID string) *Delivery {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	delivery := sc.deliveries[correlationID]
+	delete(sc.deliveries, correlationID)
+
+	// Status response window is not needed anymore
+	if delivery != nil && delivery.timer != nil {
+		delivery.timer.Stop()
+	}
+	return delivery
+}
+
+// Resolve pending delivery from status response
+func (sc *Client) resolveDelivery(status *StatusResponse) {
+	if status.CorrelationID == "" {
+		return
+	}
+	if delivery := sc.takeDelivery(status.CorrelationID); delivery != nil {
+		delivery.resolve(&StatusError{Status: *status})
+	}
+}
+
+// Record connection a tracked message was sent on
+func (sc *Client) deliverySent(correlationID string, ws WebSocketClientI) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if delivery := sc.deliveries[correlationID]; delivery != nil {
+		delivery.ws = ws
+	}
+}
+
+// Resolve pending deliveries sent on input connection with input error, once it is replaced
+// its status responses never arrive
+func (sc *Client) failDeliveriesSentOn(ws WebSocketClientI, err error) {
+	sc.mu.Lock()
+	var failed []*Delivery
+	for correlationID, delivery := range sc.deliveries {
+		if delivery.ws == ws {
+			delete(sc.deliveries, correlationID)
+			failed = append(failed, delivery)
+		}
+	}
+	sc.mu.Unlock()
+
+	for _, delivery := range failed {
+		if delivery.timer != nil {
+			delivery.timer.Stop()
+		}
+		delivery.resolve(err)
+	}
+}
+
+// Resolve every pending delivery with input error
+func (sc *Client) failDeliveries(err error) {
+	sc.mu.Lock()
+	deliveries := sc.deliveries
+	sc.deliveries = make(map[string]*Delivery)
+	sc.mu.Unlock()
+
+	for _, delivery := range deliveries {
+		if delivery.timer != nil {
+			delivery.timer.Stop()
+		}
+		delivery.resolve(err)
+	}
+}
@@ -0,0 +1,158 @@
+package signaling_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Open a master signaling client over input mock WebSocket
+func openMasterWithMock(t *testing.T, ownMockWebsocket *mockWebSocket, options ...func(*signaling.Client)) *signaling.Client {
+	// Create channel for control flow
+	c := make(chan string)
+
+	// New Signaling with mock
+	client := newMasterWithMock(t, ownMockWebsocket, options...)
+
+	// if open event
+	client.OnOpen(func() {
+		c <- "done"
+	})
+
+	// Signaling Open Connection
+	err := client.Open()
+
+	// if something wrong happened
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Wait until open
+	<-c
+	return client
+}
+
+// Get sent message from mock WebSocket
+func sentMessage(t *testing.T, ownMockWebsocket *mockWebSocket, call int) signaling.WebSocketSignalingMessageSend {
+	var message signaling.WebSocketSignalingMessageSend
+	sendCalls := []mock.Call{}
+	for _, c := range ownMockWebsocket.Calls {
+		if c.Method == "Send" {
+			sendCalls = append(sendCalls, c)
+		}
+	}
+	if err := json.Unmarshal(sendCalls[call].Arguments.Get(1).([]byte), &message); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return message
+}
+
+// Testing tracked message is delivered when there is no status response
+func TestSendSdpOfferAsyncDelivered(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client with short status response window
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket, signaling.WithStatusResponseWindow(10*time.Millisecond))
+
+	// Send tracked offer
+	delivery := client.SendSdpOfferAsync(SDPOffer, &clientID)
+
+	// Correlation id was sent
+	message := sentMessage(t, ownMockWebsocket, 0)
+	assert.NotEmpty(t, delivery.CorrelationID)
+	assert.Equal(t, delivery.CorrelationID, message.CorrelationID)
+	assert.Equal(t, clientID, message.RecipientClientID)
+
+	// Wait delivery
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, delivery.Wait(ctx))
+}
+
+// Testing tracked message fails with status response
+func TestSendSdpAnswerAsyncStatusResponse(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client with long status response window
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket, signaling.WithStatusResponseWindow(time.Hour))
+
+	// Send tracked answer
+	delivery := client.SendSdpAnswerAsync(SDPAnswer, &clientID)
+
+	// Service reports recipient is not connected
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(`{"messageType":"STATUS_RESPONSE","statusResponse":{"correlationId":"`+
+		delivery.CorrelationID+`","errorType":"InvalidArgumentException","statusCode":"400","description":"Recipient client id not connected"}}`))
+
+	// Wait delivery
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := delivery.Wait(ctx)
+
+	// ASSERTS
+	var statusErr *signaling.StatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, "400", statusErr.Status.StatusCode)
+	assert.Equal(t, "Recipient client id not connected", statusErr.Status.Description)
+	assert.Equal(t, err, delivery.Err())
+}
+
+// Testing tracked message wait honours context
+func TestSendIceCandidateAsyncContextTimeout(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client with long status response window
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket, signaling.WithStatusResponseWindow(time.Hour))
+
+	// Send tracked candidate
+	delivery := client.SendIceCandidateAsync(ICECandidate, &clientID)
+
+	// Wait delivery
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// ASSERTS
+	assert.ErrorIs(t, delivery.Wait(ctx), context.DeadlineExceeded)
+	assert.NoError(t, delivery.Err())
+
+	// Pending delivery fails when connection is closed
+	client.Close()
+	<-delivery.Done()
+	assert.ErrorIs(t, delivery.Err(), signaling.ErrDeliveryUnconfirmed)
+}
+
+// Testing tracked message fails at once if connection is not open
+func TestSendSdpOfferAsyncNotOpen(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// New Signaling
+	client, err := signaling.New(&configMaster, signaling.WithWebsocketClient(newMockWebSocket()))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// if error event
+	client.OnError(func(err error) {})
+
+	// Send tracked offer
+	delivery := client.SendSdpOfferAsync(SDPOffer, &clientID)
+
+	// ASSERTS
+	<-delivery.Done()
+	expectedErrorMsg := "could not send message because the connection to the signaling service is not open"
+	assert.EqualErrorf(t, delivery.Err(), expectedErrorMsg, "Error should be: %v, got: %v", expectedErrorMsg, delivery.Err())
+}
@@ -0,0 +1,238 @@
+package signaling
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer"
+)
+
+// Signaling errors, match them with errors.Is
+var (
+	// Config is not valid, every *ConfigError is an ErrInvalidConfig
+	ErrInvalidConfig = errors.New("invalid config")
+	// Open called when client is not CLOSED
+	ErrAlreadyOpen = errors.New("client is already open, opening, or closing")
+	// Websocket client was dialed but did not trigger Open Event within handshake timeout
+	ErrOpenTimeout = errors.New("websocket client did not open the connection within handshake timeout")
+	// Signaling client was closed before connection was OPEN
+	ErrClosed = errors.New("client was closed before the connection to the signaling service was open")
+	// Connection to signaling service is not OPEN
+	ErrNotOpen = errors.New("could not send message because the connection to the signaling service is not open")
+	// Viewer tried to send a message to a specific client
+	ErrUnexpectedRecipient = errors.New("unexpected recipient client id. As the VIEWER, messages must not be sent with a recipient client id")
+	// Sdp or ice candidate payload is not valid
+	ErrInvalidPayload = errors.New("invalid message payload")
+	// Websocket client could not write the message
+	ErrSendFailed = errors.New("could not write message to the signaling service")
+	// Master has max concurrent viewers already
+	ErrTooManyViewers = errors.New("too many concurrent viewers")
+	// Pending ice candidate dropped because client queue is full
+	ErrIceCandidateQueueFull = errors.New("pending ice candidate queue is full")
+	// Pending ice candidate dropped because sdp message of its sender did not arrive in time
+	ErrIceCandidateExpired = errors.New("pending ice candidate expired")
+	// Pending ice candidate dropped because its sender started a new negotiation
+	ErrIceCandidateStale = errors.New("pending ice candidate belongs to a previous negotiation")
+	// Signaling client gives up reconnecting
+	ErrReconnectExhausted = errors.New("reconnect attempts exhausted")
+	// Connection closed before a tracked message is confirmed
+	ErrDeliveryUnconfirmed = errors.New("connection closed before message delivery was confirmed")
+	// Channel endpoint can not be signed
+	ErrInvalidEndpoint = signer.ErrInvalidEndpoint
+	// Credentials do not exist or they are expired
+	ErrCredentialsExpired = signer.ErrCredentialsExpired
+	// Signing time too far from AWS clock, every *ClockSkewError is an ErrClockSkew
+	ErrClockSkew = errors.New("signature rejected because of clock skew")
+	// Websocket handshake rejected with 403, like a bad signature or missing permissions
+	ErrForbidden = errors.New("signaling service rejected the connection as forbidden")
+	// Websocket handshake rejected with 404, like a wrong channel ARN
+	ErrChannelNotFound = errors.New("signaling channel not found")
+	// Websocket handshake rejected with 429, too many connections in a short time
+	ErrThrottled = errors.New("signaling service throttled the connection")
+)
+
+// Error for an invalid Config field
+type ConfigError struct {
+	Field  string // Config field
+	Reason string // What is wrong
+}
+
+// Error message with field and reason
+func (e *ConfigError) Error() string {
+	return e.Field + " " + e.Reason
+}
+
+// ConfigError is an ErrInvalidConfig
+func (e *ConfigError) Is(target error) bool {
+	return target == ErrInvalidConfig
+}
+
+// Error from websocket connection
+type TransportError struct {
+	Op  string // Operation: dial, read or write
+	Err error  // Websocket library error
+}
+
+// Error message with operation
+func (e *TransportError) Error() string {
+	return "websocket " + e.Op + ": " + e.Err.Error()
+}
+
+// Unwrap returns websocket library error
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// Error for a websocket handshake answered by signaling service without upgrading the connection
+type HandshakeError struct {
+	StatusCode int         // Response status code
+	Header     http.Header // Response headers
+	Body       []byte      // Response body, bounded to first bytes
+	Err        error       // Websocket library error
+}
+
+// Error message with status code and body
+func (e *HandshakeError) Error() string {
+	message := "websocket handshake status " + strconv.Itoa(e.StatusCode)
+	if body := strings.TrimSpace(string(e.Body)); body != "" {
+		message += ": " + body
+	}
+	return message
+}
+
+// HandshakeError is an ErrForbidden, ErrChannelNotFound or ErrThrottled by status code
+func (e *HandshakeError) Is(target error) bool {
+	switch e.StatusCode {
+	case http.StatusForbidden:
+		return target == ErrForbidden
+	case http.StatusNotFound:
+		return target == ErrChannelNotFound
+	case http.StatusTooManyRequests:
+		return target == ErrThrottled
+	}
+	return false
+}
+
+// Code returns AWS error code of handshake response, from its error type header or its body,
+// empty when it has none
+func (e *HandshakeError) Code() string {
+	// Error type header looks like "ExpiredTokenException:http://internal.amazon.com/..."
+	if code, _, _ := strings.Cut(e.Header.Get("X-Amzn-Errortype"), ":"); code != "" {
+		return code
+	}
+
+	var body struct {
+		Type string `json:"__type"`
+		Code string `json:"code"`
+	}
+	if json.Unmarshal(e.Body, &body) != nil {
+		return ""
+	}
+	// Type may have a namespace, like "com.amazonaws.kinesisvideo#ExpiredTokenException"
+	if body.Type != "" {
+		return body.Type[strings.LastIndex(body.Type, "#")+1:]
+	}
+	return body.Code
+}
+
+// Unwrap returns websocket library error
+func (e *HandshakeError) Unwrap() error {
+	return e.Err
+}
+
+// Error for a websocket handshake rejected because signing time is too far from AWS clock
+type ClockSkewError struct {
+	ServerTime time.Time // Date of handshake response, zero when it has no date
+	Err        error     // Websocket library error
+}
+
+// Error message with websocket library error
+func (e *ClockSkewError) Error() string {
+	return ErrClockSkew.Error() + ": " + e.Err.Error()
+}
+
+// ClockSkewError is an ErrClockSkew
+func (e *ClockSkewError) Is(target error) bool {
+	return target == ErrClockSkew
+}
+
+// Unwrap returns websocket library error
+func (e *ClockSkewError) Unwrap() error {
+	return e.Err
+}
+
+// Error reported by signaling service with a status response
+type StatusError struct {
+	Status StatusResponse
+}
+
+// Error message from status response
+func (e *StatusError) Error() string {
+	return "signaling service status " + e.Status.StatusCode + " " + e.Status.ErrorType + ": " + e.Status.Description
+}
+
+// IsRetryable tells if an operation failed with input error may succeed when it is tried again.
+// Rejected handshakes are classified as reconnect attempts with default reconnect policy
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// Status response, only service side errors and throttling are retryable
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		code, convErr := strconv.Atoi(statusErr.Status.StatusCode)
+		return convErr == nil && (code >= 500 || code == 429)
+	}
+
+	// Clock is corrected before next attempt
+	if errors.Is(err, ErrClockSkew) {
+		return true
+	}
+
+	// Handshake rejected because of credentials, they are refreshed before next attempt
+	if isCredentialsRejection(err) {
+		return true
+	}
+
+	// Handshake response, retryable unless reconnect attempts stop on it with default reconnect policy
+	var handshakeErr *HandshakeError
+	if errors.As(err, &handshakeErr) {
+		return !isRejectedBy(err, nil)
+	}
+
+	switch {
+	// Caller decisions and wrong usage are not retryable
+	case errors.Is(err, context.Canceled),
+		errors.Is(err, ErrInvalidConfig),
+		errors.Is(err, ErrAlreadyOpen),
+		errors.Is(err, ErrClosed),
+		errors.Is(err, ErrUnexpectedRecipient),
+		errors.Is(err, ErrInvalidPayload),
+		errors.Is(err, ErrInvalidEndpoint),
+		errors.Is(err, ErrReconnectExhausted):
+		return false
+	// Connection problems and expired credentials, that can be refreshed, are retryable
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, ErrOpenTimeout),
+		errors.Is(err, ErrNotOpen),
+		errors.Is(err, ErrSendFailed),
+		errors.Is(err, ErrDeliveryUnconfirmed),
+		errors.Is(err, ErrCredentialsExpired):
+		return true
+	}
+
+	// Websocket and network errors
+	var transportErr *TransportError
+	if errors.As(err, &transportErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,107 @@
+package signaling_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer"
+	"github.com/stretchr/testify/assert"
+)
+
+// Testing New Signaling config errors can be matched
+func TestConstructorConfigError(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Delete Region from config
+	configMaster.Region = nil
+
+	// New Signaling
+	_, err := signaling.New(&configMaster)
+
+	// ASSERTS
+	assert.ErrorIs(t, err, signaling.ErrInvalidConfig)
+	var configErr *signaling.ConfigError
+	assert.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "region", configErr.Field)
+}
+
+// Testing errors classification
+func TestIsRetryable(t *testing.T) {
+	// Retryable errors
+	retryable := []error{
+		signaling.ErrNotOpen,
+		signaling.ErrOpenTimeout,
+		fmt.Errorf("%w: %w", signaling.ErrSendFailed, errors.New("broken pipe")),
+		&signaling.TransportError{Op: "dial", Err: errors.New("connection refused")},
+		&signer.CredentialsError{Err: errors.New("expired")},
+		&signaling.StatusError{Status: signaling.StatusResponse{StatusCode: "500"}},
+		&signaling.StatusError{Status: signaling.StatusResponse{StatusCode: "429"}},
+		context.DeadlineExceeded,
+		signaling.ErrDeliveryUnconfirmed,
+		&signaling.HandshakeError{StatusCode: 429},
+		&signaling.HandshakeError{StatusCode: 503},
+		&signaling.ClockSkewError{Err: &signaling.HandshakeError{StatusCode: 403}},
+		&signaling.TransportError{Op: "dial", Err: &signaling.HandshakeError{StatusCode: 403}},
+		&signaling.TransportError{Op: "dial", Err: &signaling.HandshakeError{StatusCode: 403, Header: http.Header{"X-Amzn-Errortype": {"ExpiredTokenException:"}}}},
+		&signaling.HandshakeError{StatusCode: 403, Body: []byte(`{"__type":"com.amazonaws.kinesisvideo#InvalidSignatureException"}`)},
+		&signaling.HandshakeError{StatusCode: 400},
+	}
+	for _, err := range retryable {
+		assert.True(t, signaling.IsRetryable(err), err.Error())
+	}
+
+	// Not retryable errors
+	notRetryable := []error{
+		nil,
+		errors.New("unknown"),
+		&signaling.ConfigError{Field: "region", Reason: "cannot be nil"},
+		signaling.ErrAlreadyOpen,
+		signaling.ErrClosed,
+		signaling.ErrUnexpectedRecipient,
+		signaling.ErrReconnectExhausted,
+		&signer.EndpointError{Endpoint: "https://kvs.awsamazon.com", Reason: "is not valid"},
+		&signaling.StatusError{Status: signaling.StatusResponse{StatusCode: "400"}},
+		context.Canceled,
+		&signaling.TransportError{Op: "dial", Err: &signaling.HandshakeError{StatusCode: 404}},
+		&signaling.HandshakeError{StatusCode: 404},
+	}
+	for _, err := range notRetryable {
+		assert.False(t, signaling.IsRetryable(err), fmt.Sprint(err))
+	}
+}
+
+// Testing handshake errors are matched by status code
+func TestHandshakeErrorIs(t *testing.T) {
+	forbidden := &signaling.HandshakeError{StatusCode: 403, Body: []byte(`{"message":"Forbidden"}` + "\n")}
+	notFound := &signaling.HandshakeError{StatusCode: 404}
+	throttled := &signaling.HandshakeError{StatusCode: 429}
+
+	// ASSERTS
+	assert.ErrorIs(t, forbidden, signaling.ErrForbidden)
+	assert.NotErrorIs(t, forbidden, signaling.ErrChannelNotFound)
+	assert.ErrorIs(t, notFound, signaling.ErrChannelNotFound)
+	assert.ErrorIs(t, throttled, signaling.ErrThrottled)
+	assert.NotErrorIs(t, &signaling.HandshakeError{StatusCode: 500}, signaling.ErrThrottled)
+	assert.Equal(t, `websocket handshake status 403: {"message":"Forbidden"}`, forbidden.Error())
+	assert.Equal(t, "websocket handshake status 404", notFound.Error())
+}
+
+// Testing handshake error code is read from error type header or body
+func TestHandshakeErrorCode(t *testing.T) {
+	fromHeader := &signaling.HandshakeError{StatusCode: 403, Header: http.Header{"X-Amzn-Errortype": {"ExpiredTokenException:http://internal.amazon.com/"}}}
+	fromType := &signaling.HandshakeError{StatusCode: 403, Body: []byte(`{"__type":"com.amazonaws.kinesisvideo#InvalidSignatureException","message":"Signature expired"}`)}
+	fromCode := &signaling.HandshakeError{StatusCode: 403, Body: []byte(`{"code":"SignatureDoesNotMatch"}`)}
+	withoutCode := &signaling.HandshakeError{StatusCode: 403, Body: []byte(`{"message":"The security token included in the request is expired"}`)}
+
+	// ASSERTS
+	assert.Equal(t, "ExpiredTokenException", fromHeader.Code())
+	assert.Equal(t, "InvalidSignatureException", fromType.Code())
+	assert.Equal(t, "SignatureDoesNotMatch", fromCode.Code())
+	assert.Equal(t, "", withoutCode.Code())
+	assert.Equal(t, "", (&signaling.HandshakeError{StatusCode: 404, Body: []byte("Not Found")}).Code())
+}
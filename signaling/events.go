@@ -0,0 +1,185 @@
+package signaling
+
+import "time"
+
+// Event functions are read under lock and called out of it, so they can use signaling client.
+// Events are published to event stream too, after event function
+
+// Trigger Open Event if there is a function for it
+func (sc *Client) emitOpen() {
+	sc.mu.Lock()
+	f := sc.onOpen
+	sc.mu.Unlock()
+	if f != nil {
+		f()
+	}
+	sc.publish(OpenEvent{})
+}
+
+// Trigger State Change Event if there is a function for it
+func (sc *Client) emitStateChange(old ReadyStateType, new ReadyStateType) {
+	sc.mu.Lock()
+	f := sc.onStateChange
+	sc.mu.Unlock()
+	if f != nil {
+		f(old, new)
+	}
+	sc.publish(StateChangeEvent{Old: old, New: new})
+}
+
+// Trigger Close Event if there is a function for it
+func (sc *Client) emitClose() {
+	sc.mu.Lock()
+	f := sc.onClose
+	sc.mu.Unlock()
+	if f != nil {
+		f()
+	}
+	sc.publish(CloseEvent{})
+}
+
+// Trigger Error Event if there is a function for it
+func (sc *Client) emitError(err error) {
+	sc.mu.Lock()
+	f := sc.onError
+	sc.mu.Unlock()
+	if f != nil {
+		f(err)
+	}
+	sc.publish(ErrorEvent{Err: err})
+}
+
+// Trigger Sdp Offer Event if there is a function for it
+func (sc *Client) emitSdpOffer(offer *string, remoteClientID *string) {
+	sc.mu.Lock()
+	f := sc.onSdpOffer
+	sc.mu.Unlock()
+	if f != nil {
+		f(offer, remoteClientID)
+	}
+	sc.emitOffer(*offer, stringValue(remoteClientID))
+	sc.publish(SdpOfferEvent{Offer: *offer, ClientID: stringValue(remoteClientID)})
+}
+
+// Trigger Sdp Answer Event if there is a function for it
+func (sc *Client) emitSdpAnswer(answer *string, clientID *string) {
+	sc.mu.Lock()
+	f := sc.onSdpAnswer
+	sc.mu.Unlock()
+	if f != nil {
+		f(answer, clientID)
+	}
+	sc.emitAnswer(*answer, stringValue(clientID))
+	sc.publish(SdpAnswerEvent{Answer: *answer, ClientID: stringValue(clientID)})
+}
+
+// Trigger Ice Candidate Event if there is a function for it
+func (sc *Client) emitIceCandidate(iceCandidate *string, clientID *string) {
+	sc.mu.Lock()
+	f := sc.onIceCandidate
+	sc.mu.Unlock()
+	if f != nil {
+		f(iceCandidate, clientID)
+	}
+	sc.emitCandidate(*iceCandidate, stringValue(clientID))
+	sc.publish(IceCandidateEvent{IceCandidate: *iceCandidate, ClientID: stringValue(clientID)})
+}
+
+// Trigger Go Away Event if there is a function for it
+func (sc *Client) emitGoAway(goAway *GoAway) {
+	sc.mu.Lock()
+	f := sc.onGoAway
+	sc.mu.Unlock()
+	if f != nil {
+		f(goAway)
+	}
+	sc.publish(GoAwayEvent{GoAway: *goAway})
+}
+
+// Trigger Reconnect ICE Server Event if there is a function for it
+func (sc *Client) emitReconnectIceServer(msg *ReconnectIceServer) {
+	sc.mu.Lock()
+	f := sc.onReconnectIceServer
+	sc.mu.Unlock()
+	if f != nil {
+		f(msg)
+	}
+	sc.publish(ReconnectIceServerEvent{ReconnectIceServer: *msg})
+}
+
+// Trigger Credentials Rotated Event if there is a function for it
+func (sc *Client) emitCredentialsRotated(accessKeyID string, expires time.Time) {
+	sc.mu.Lock()
+	f := sc.onCredentialsRotated
+	sc.mu.Unlock()
+	if f != nil {
+		f(accessKeyID, expires)
+	}
+	sc.publish(CredentialsRotatedEvent{AccessKeyID: accessKeyID, Expires: expires})
+}
+
+// Trigger Clock Skew Corrected Event if there is a function for it
+func (sc *Client) emitClockSkewCorrected(offset time.Duration) {
+	sc.mu.Lock()
+	f := sc.onClockSkewCorrected
+	sc.mu.Unlock()
+	if f != nil {
+		f(offset)
+	}
+	sc.publish(ClockSkewCorrectedEvent{Offset: offset})
+}
+
+// Trigger Status Response Event if there is a function for it
+func (sc *Client) emitStatusResponse(status *StatusResponse) {
+	sc.mu.Lock()
+	f := sc.onStatusResponse
+	sc.mu.Unlock()
+	if f != nil {
+		f(status)
+	}
+	sc.publish(StatusResponseEvent{Status: *status})
+}
+
+// Trigger Reconnecting Event if there is a function for it
+func (sc *Client) emitReconnecting(attempt int, delay time.Duration) {
+	sc.mu.Lock()
+	f := sc.onReconnecting
+	sc.mu.Unlock()
+	if f != nil {
+		f(attempt, delay)
+	}
+	sc.publish(ReconnectingEvent{Attempt: attempt, Delay: delay})
+}
+
+// Trigger Reconnected Event if there is a function for it
+func (sc *Client) emitReconnected(attempt int) {
+	sc.mu.Lock()
+	f := sc.onReconnected
+	sc.mu.Unlock()
+	if f != nil {
+		f(attempt)
+	}
+	sc.publish(ReconnectedEvent{Attempt: attempt})
+}
+
+// Trigger Session Change Event if there is a function for it
+func (sc *Client) emitSessionChange(session Session) {
+	sc.mu.Lock()
+	f := sc.onSessionChange
+	sc.mu.Unlock()
+	if f != nil {
+		f(session)
+	}
+	sc.publish(SessionChangeEvent{Session: session})
+}
+
+// Trigger Ice Candidate Dropped Event if there is a function for it
+func (sc *Client) emitIceCandidateDropped(iceCandidate string, clientID string, reason error) {
+	sc.mu.Lock()
+	f := sc.onIceCandidateDropped
+	sc.mu.Unlock()
+	if f != nil {
+		f(iceCandidate, clientID, reason)
+	}
+	sc.publish(IceCandidateDroppedEvent{IceCandidate: iceCandidate, ClientID: clientID, Reason: reason})
+}
@@ -0,0 +1,251 @@
+package signaling_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// Testing dial error carries handshake response
+func TestHandshakeError(t *testing.T) {
+	// Signaling service stand-in for a wrong channel ARN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amzn-Requestid", "request-id")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"Channel not found"}` + strings.Repeat(" ", 10000)))
+	}))
+	defer server.Close()
+
+	// Websocket client
+	ws := &signaling.WebSocketClient{}
+	ws.OnError(func(err error) {})
+	assert.NoError(t, ws.SetURL("ws"+strings.TrimPrefix(server.URL, "http")))
+	err := ws.DialContext(context.Background())
+
+	// ASSERTS
+	assert.ErrorIs(t, err, signaling.ErrChannelNotFound)
+	assert.ErrorIs(t, err, websocket.ErrBadHandshake)
+	assert.False(t, signaling.IsRetryable(err))
+	var handshakeErr *signaling.HandshakeError
+	assert.ErrorAs(t, err, &handshakeErr)
+	assert.Equal(t, http.StatusNotFound, handshakeErr.StatusCode)
+	assert.Equal(t, "request-id", handshakeErr.Header.Get("X-Amzn-Requestid"))
+	assert.True(t, strings.HasPrefix(string(handshakeErr.Body), `{"message":"Channel not found"}`))
+	assert.LessOrEqual(t, len(handshakeErr.Body), 4*1024)
+}
+
+// Testing reconnect attempts stop only when signaling service rejects them with a fatal status
+func TestReconnectStopsWhenRejected(t *testing.T) {
+	for _, test := range []struct {
+		name       string // Test name
+		status     int    // Status of rejected handshakes
+		fatal      []int  // Fatal statuses of reconnect policy
+		accepted   int    // Handshake accepted again, 0 for none
+		err        error  // Error of rejected handshakes
+		handshakes int    // Handshakes until client reconnects or goes offline
+		reconnects bool   // Client reconnects
+	}{
+		{name: "channel not found", status: http.StatusNotFound, err: signaling.ErrChannelNotFound, handshakes: 2},
+		{name: "forbidden retried", status: http.StatusForbidden, accepted: 4, err: signaling.ErrForbidden, handshakes: 4, reconnects: true},
+		{name: "forbidden fatal", status: http.StatusForbidden, fatal: []int{http.StatusForbidden}, err: signaling.ErrForbidden, handshakes: 2},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			// Load Initial values
+			InitInfo()
+
+			// Signaling service stand-in, it accepts first connection and closes it, then rejects the rest
+			var mu sync.Mutex
+			handshakes := 0
+			upgrader := websocket.Upgrader{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				handshakes++
+				current := handshakes
+				mu.Unlock()
+
+				if current > 1 && current != test.accepted {
+					w.WriteHeader(test.status)
+					_, _ = w.Write([]byte(`{"message":"User is not authorized to perform: kinesisvideo:ConnectAsMaster"}`))
+					return
+				}
+				conn, err := upgrader.Upgrade(w, r, nil)
+				if err != nil {
+					return
+				}
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					if current == 1 {
+						conn.Close()
+					}
+				}()
+			}))
+			defer server.Close()
+
+			// Signaling client with unlimited reconnect attempts
+			client := newStandInClient(t, server, signaling.WithReconnectPolicy(signaling.ReconnectPolicy{
+				InitialInterval:  time.Millisecond,
+				FatalStatusCodes: test.fatal,
+			}))
+			defer client.Close()
+
+			// if error event
+			var receivedErr error
+			client.OnError(func(err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				if receivedErr == nil && errors.Is(err, test.err) {
+					receivedErr = err
+				}
+			})
+
+			// if reconnected or close event
+			done := make(chan bool, 1)
+			client.OnReconnected(func(attempt int) {
+				done <- true
+			})
+			client.OnClose(func() {
+				done <- false
+			})
+
+			// Signaling Open Connection
+			assert.NoError(t, client.OpenContext(context.Background()))
+
+			// Wait until client reconnects or goes offline
+			var reconnected bool
+			select {
+			case reconnected = <-done:
+			case <-time.After(2 * time.Second):
+				t.Fatalf("Signaling client kept reconnecting")
+			}
+
+			// ASSERTS
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, test.reconnects, reconnected)
+			assert.Equal(t, test.handshakes, handshakes)
+			assert.ErrorIs(t, receivedErr, test.err)
+			if !test.reconnects {
+				assert.Equal(t, signaling.StateClosed, client.State())
+			}
+		})
+	}
+}
+
+// Credentials provider giving new credentials on every retrieve, like rotated ones
+type rotatingProvider struct {
+	credentials.Expiry
+	mu       sync.Mutex
+	retrieve int
+}
+
+// Retrieve next credentials
+func (p *rotatingProvider) Retrieve() (credentials.Value, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.retrieve++
+	p.SetExpiration(time.Now().Add(time.Hour), 0)
+	return credentials.Value{AccessKeyID: "ASIA" + strconv.Itoa(p.retrieve), SecretAccessKey: "SECRET", SessionToken: "TOKEN"}, nil
+}
+
+// Websocket client dialing signed wss urls of a plain stand-in over ws
+type plainWebSocket struct {
+	signaling.WebSocketClient
+}
+
+// Set url with ws scheme
+func (ws *plainWebSocket) SetURL(url string) error {
+	return ws.WebSocketClient.SetURL("ws://" + strings.TrimPrefix(url, "wss://"))
+}
+
+// Testing reconnect attempt rejected because of credentials is tried again once with refreshed credentials
+func TestReconnectRefreshesRejectedCredentials(t *testing.T) {
+	for _, test := range []struct {
+		name       string // Test name
+		accepted   string // Access key accepted after first connection
+		handshakes int    // Handshakes until client reconnects or goes offline
+		reconnects bool   // Client reconnects
+	}{
+		{name: "refreshed credentials accepted", accepted: "ASIA2", handshakes: 3, reconnects: true},
+		{name: "refreshed credentials rejected", accepted: "", handshakes: 5, reconnects: false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			// Load Initial values
+			InitInfo()
+
+			// Signaling service stand-in, it accepts first connection and closes it, then first credentials expire
+			var mu sync.Mutex
+			handshakes := 0
+			upgrader := websocket.Upgrader{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				handshakes++
+				first := handshakes == 1
+				mu.Unlock()
+
+				accessKeyID := strings.Split(r.URL.Query().Get("X-Amz-Credential"), "/")[0]
+				if !first && accessKeyID != test.accepted {
+					w.Header().Set("X-Amzn-Errortype", "ExpiredTokenException:http://internal.amazon.com/coral/com.amazon.coral.service/")
+					w.WriteHeader(http.StatusForbidden)
+					_, _ = w.Write([]byte(`{"message":"The security token included in the request is expired"}`))
+					return
+				}
+				conn, err := upgrader.Upgrade(w, r, nil)
+				if err != nil {
+					return
+				}
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					if first {
+						conn.Close()
+					}
+				}()
+			}))
+			defer server.Close()
+
+			// Signaling client with default signer and two reconnect attempts, each one refreshing credentials once
+			endpoint := "wss" + strings.TrimPrefix(server.URL, "http")
+			configMaster.ChannelEndpoint = &endpoint
+			client, err := signaling.New(&configMaster, signaling.WithCredentialsProvider(&rotatingProvider{}),
+				signaling.WithWebsocketClient(&plainWebSocket{}), signaling.WithReconnectPolicy(signaling.ReconnectPolicy{MaxAttempts: 2, InitialInterval: time.Millisecond}))
+			assert.NoError(t, err)
+			defer client.Close()
+
+			// if reconnected or close event
+			done := make(chan bool, 1)
+			client.OnReconnected(func(attempt int) {
+				done <- true
+			})
+			client.OnClose(func() {
+				done <- false
+			})
+
+			// Signaling Open Connection
+			assert.NoError(t, client.OpenContext(context.Background()))
+
+			// Wait until client reconnects or goes offline
+			var reconnected bool
+			select {
+			case reconnected = <-done:
+			case <-time.After(2 * time.Second):
+				t.Fatalf("Signaling client kept reconnecting")
+			}
+
+			// ASSERTS
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, test.reconnects, reconnected)
+			assert.Equal(t, test.handshakes, handshakes)
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package signaling_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Testing blocking open returns when connection is OPEN
+func TestOpenContext(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+	// New Signaling with mock
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner), signaling.WithWebsocketClient(newMockWebSocket()))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// ASSERTS
+	assert.NoError(t, client.OpenContext(context.Background()))
+	assert.Equal(t, signaling.StateOpen, client.State())
+	assert.ErrorIs(t, client.OpenContext(context.Background()), signaling.ErrAlreadyOpen)
+}
+
+// Testing blocking open returns signing error
+func TestOpenContextSignError(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create mock Signer failing
+	signErr := errors.New("MockError")
+	ownMockSigner := &mockSigner{}
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return("", signErr)
+
+	// New Signaling with mock
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner), signaling.WithWebsocketClient(newMockWebSocket()))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Error Event is still triggered
+	errorEvents := 0
+	client.OnError(func(err error) {
+		errorEvents++
+	})
+
+	// ASSERTS
+	assert.ErrorIs(t, client.OpenContext(context.Background()), signErr)
+	assert.Equal(t, 1, errorEvents)
+	assert.Equal(t, signaling.StateClosed, client.State())
+}
+
+// Testing blocking open closes signaling client when context is done
+func TestOpenContextTimeout(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+	// Create mock WebSocket with a dial blocked until test ends
+	release := make(chan string)
+	ownMockWebsocket := &mockWebSocket{}
+	ownMockWebsocket.On("Dial").Return(nil).Run(func(args mock.Arguments) {
+		<-release
+	})
+	ownMockWebsocket.On("SetURL", mock.Anything).Return(nil)
+	ownMockWebsocket.On("OnMessage", mock.Anything, mock.Anything).Return()
+	ownMockWebsocket.On("Close").Return()
+
+	// New Signaling with mock
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner), signaling.WithWebsocketClient(ownMockWebsocket))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Open Event must not be triggered
+	client.OnOpen(func() {
+		t.Errorf("Unexpected open")
+	})
+
+	// Signaling Open Connection with short timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = client.OpenContext(ctx)
+
+	// ASSERTS
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, signaling.StateClosed, client.State())
+	ownMockWebsocket.AssertCalled(t, "Close")
+
+	// Dial ends after signaling client was closed
+	release <- "done"
+}
+
+// Testing default websocket client gives up when opening handshake takes too long
+func TestWebSocketClientHandshakeTimeout(t *testing.T) {
+	// Server accepting connections but never answering
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	// Websocket client with short handshake timeout
+	ws := &signaling.WebSocketClient{HandshakeTimeout: 50 * time.Millisecond}
+	ws.OnError(func(err error) {})
+	assert.NoError(t, ws.SetURL("ws://"+listener.Addr().String()))
+
+	// ASSERTS
+	start := time.Now()
+	err = ws.DialContext(context.Background())
+	var transportErr *signaling.TransportError
+	assert.True(t, errors.As(err, &transportErr))
+	assert.Equal(t, "dial", transportErr.Op)
+	assert.True(t, signaling.IsRetryable(err))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+// WebSocket mock neither triggering Open Event when dialed nor Close Event when closed
+type silentWebSocket struct {
+	*mockWebSocket
+}
+
+// Mock Websocket Dial Function without Open Event
+func (m *silentWebSocket) Dial() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+// Mock Websocket Close Function without Close Event
+func (m *silentWebSocket) Close() {
+	m.Called()
+}
+
+// Testing blocking open leaves signaling client CLOSED at once when context is done while dialing
+func TestOpenContextTimeoutWhileDialing(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+	// Create silent mock WebSocket with a dial blocked until test releases it
+	release := make(chan string)
+	ownMockWebsocket := &silentWebSocket{&mockWebSocket{}}
+	ownMockWebsocket.On("Dial").Return(nil).Run(func(args mock.Arguments) {
+		<-release
+	})
+	ownMockWebsocket.On("SetURL", mock.Anything).Return(nil)
+	ownMockWebsocket.On("OnMessage", mock.Anything, mock.Anything).Return()
+	ownMockWebsocket.On("Close").Return()
+
+	// New Signaling with mock
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner), signaling.WithWebsocketClient(ownMockWebsocket))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Close Event is triggered once
+	closeEvents := make(chan string, 2)
+	client.OnClose(func() {
+		closeEvents <- "close"
+	})
+
+	// Signaling Open Connection with short timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = client.OpenContext(ctx)
+
+	// ASSERTS
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, signaling.StateClosed, client.State())
+	assert.Len(t, closeEvents, 1)
+
+	// Connection opened by first dial is not used
+	release <- "done"
+	ownMockWebsocket.onOpen()
+	assert.Equal(t, signaling.StateClosed, client.State())
+	assert.Len(t, closeEvents, 1)
+}
+
+// Testing connection attempt gives up when websocket client never triggers Open Event
+func TestOpenTimeoutWithoutOpenEvent(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+	// Create silent mock WebSocket dialing without Open Event
+	ownMockWebsocket := &silentWebSocket{&mockWebSocket{}}
+	ownMockWebsocket.On("Dial").Return(nil)
+	ownMockWebsocket.On("SetURL", mock.Anything).Return(nil)
+	ownMockWebsocket.On("OnMessage", mock.Anything, mock.Anything).Return()
+	ownMockWebsocket.On("Close").Return()
+
+	// New Signaling with mock and short handshake timeout
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner), signaling.WithWebsocketClient(ownMockWebsocket),
+		signaling.WithHandshakeTimeout(20*time.Millisecond))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Error Event is triggered
+	errorEvents := make(chan error, 1)
+	client.OnError(func(err error) {
+		errorEvents <- err
+	})
+
+	// ASSERTS
+	start := time.Now()
+	assert.ErrorIs(t, client.OpenContext(context.Background()), signaling.ErrOpenTimeout)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.ErrorIs(t, <-errorEvents, signaling.ErrOpenTimeout)
+	assert.Equal(t, signaling.StateClosed, client.State())
+
+	// Late Open Event does not open signaling client and its connection is closed
+	ownMockWebsocket.onOpen()
+	assert.Equal(t, signaling.StateClosed, client.State())
+	ownMockWebsocket.AssertCalled(t, "Close")
+}
+
+// Testing results of a dial abandoned by blocking open do not affect next open
+func TestOpenAfterAbandonedDial(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+	// Create silent mock WebSocket, first dial is blocked until test releases it and then fails
+	release := make(chan string)
+	dialErr := errors.New("MockError")
+	ownMockWebsocket := &silentWebSocket{&mockWebSocket{}}
+	ownMockWebsocket.On("Dial").Return(dialErr).Run(func(args mock.Arguments) {
+		<-release
+	}).Once()
+	ownMockWebsocket.On("Dial").Return(nil).Run(func(args mock.Arguments) {
+		ownMockWebsocket.onOpen()
+	})
+	ownMockWebsocket.On("SetURL", mock.Anything).Return(nil)
+	ownMockWebsocket.On("OnMessage", mock.Anything, mock.Anything).Return()
+	ownMockWebsocket.On("Close").Return()
+
+	// New Signaling with mock
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner), signaling.WithWebsocketClient(ownMockWebsocket))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Error Event of abandoned dial must not be triggered
+	client.OnError(func(err error) {
+		t.Errorf("Unexpected error: %v", err)
+	})
+
+	// if open event
+	opened := make(chan string, 1)
+	client.OnOpen(func() {
+		opened <- "open"
+	})
+
+	// Signaling Open Connection with short timeout, then open again at once
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, client.OpenContext(ctx), context.DeadlineExceeded)
+	assert.NoError(t, client.Open())
+
+	// Second dial waits for first one
+	time.Sleep(20 * time.Millisecond)
+	ownMockWebsocket.AssertNumberOfCalls(t, "Dial", 1)
+
+	// First dial fails after second open
+	release <- "done"
+	select {
+	case <-opened:
+	case <-time.After(time.Second):
+		t.Fatalf("Signaling client did not open")
+	}
+
+	// ASSERTS
+	assert.Equal(t, signaling.StateOpen, client.State())
+	ownMockWebsocket.AssertNumberOfCalls(t, "Dial", 2)
+}
@@ -0,0 +1,182 @@
+package signaling
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Session description type
+type SDPType string
+
+// Session description types exchanged over signaling channel
+const (
+	SDPTypeOffer  SDPType = "offer"
+	SDPTypeAnswer SDPType = "answer"
+)
+
+// Session description, payload of sdp offer and sdp answer messages, same as RTCSessionDescriptionInit
+type SessionDescription struct {
+	Type SDPType `json:"type"`
+	SDP  string  `json:"sdp"`
+}
+
+// Ice candidate, payload of ice candidate messages, same as RTCIceCandidateInit
+type IceCandidateInit struct {
+	Candidate        string  `json:"candidate"`
+	SDPMid           *string `json:"sdpMid,omitempty"`
+	SDPMLineIndex    *uint16 `json:"sdpMLineIndex,omitempty"`
+	UsernameFragment *string `json:"usernameFragment,omitempty"`
+}
+
+// Validate session description has expected type and a sdp
+func (d SessionDescription) Validate(expected SDPType) error {
+	if d.Type != expected {
+		return fmt.Errorf("%w: session description type is '%s', expected '%s'", ErrInvalidPayload, d.Type, expected)
+	}
+	if d.SDP == "" {
+		return fmt.Errorf("%w: session description sdp is empty", ErrInvalidPayload)
+	}
+	return nil
+}
+
+// Validate ice candidate is bound to a media section, an empty candidate means end of candidates
+func (c IceCandidateInit) Validate() error {
+	if c.Candidate != "" && c.SDPMid == nil && c.SDPMLineIndex == nil {
+		return fmt.Errorf("%w: ice candidate needs sdpMid or sdpMLineIndex", ErrInvalidPayload)
+	}
+	return nil
+}
+
+// ParseSessionDescription decodes and validates a sdp offer or sdp answer message payload
+func ParseSessionDescription(payload string, expected SDPType) (SessionDescription, error) {
+	var desc SessionDescription
+	if err := json.Unmarshal([]byte(payload), &desc); err != nil {
+		return SessionDescription{}, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+	}
+	return desc, desc.Validate(expected)
+}
+
+// ParseIceCandidateInit decodes and validates an ice candidate message payload
+func ParseIceCandidateInit(payload string) (IceCandidateInit, error) {
+	var candidate IceCandidateInit
+	if err := json.Unmarshal([]byte(payload), &candidate); err != nil {
+		return IceCandidateInit{}, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+	}
+	return candidate, candidate.Validate()
+}
+
+// On Offer Event Function, typed version of On Sdp Offer Event. Invalid payloads trigger Error Event
+func (sc *Client) OnOffer(f func(desc SessionDescription, from string)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.onOffer = f
+}
+
+// On Answer Event Function, typed version of On Sdp Answer Event. Invalid payloads trigger Error Event
+func (sc *Client) OnAnswer(f func(desc SessionDescription, from string)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.onAnswer = f
+}
+
+// On Candidate Event Function, typed version of On ICE Candidate Event. Invalid payloads trigger Error Event
+func (sc *Client) OnCandidate(f func(candidate IceCandidateInit, from string)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.onCandidate = f
+}
+
+// Sender signaling typed Sdp Offer Messages, empty recipient when there is no recipient client.
+// Invalid payloads are not sent, they trigger Error Event and are returned like any other send error
+func (sc *Client) SendOffer(ctx context.Context, desc SessionDescription, to string) error {
+	if err := desc.Validate(SDPTypeOffer); err != nil {
+		// Trigger Error Event, as any other send failure
+		sc.emitError(err)
+		return err
+	}
+	return sc.sendPayload(ctx, sdpOffer, desc, to)
+}
+
+// Sender signaling typed Sdp Answer Messages, empty recipient when there is no recipient client
+func (sc *Client) SendAnswer(ctx context.Context, desc SessionDescription, to string) error {
+	if err := desc.Validate(SDPTypeAnswer); err != nil {
+		// Trigger Error Event, as any other send failure
+		sc.emitError(err)
+		return err
+	}
+	return sc.sendPayload(ctx, sdpAnswer, desc, to)
+}
+
+// Sender signaling typed Ice Candidate Messages, empty recipient when there is no recipient client
+func (sc *Client) SendCandidate(ctx context.Context, candidate IceCandidateInit, to string) error {
+	if err := candidate.Validate(); err != nil {
+		// Trigger Error Event, as any other send failure
+		sc.emitError(err)
+		return err
+	}
+	return sc.sendPayload(ctx, iceCandidate, candidate, to)
+}
+
+// Encode payload and send it
+func (sc *Client) sendPayload(ctx context.Context, msgType MessageType, payload interface{}, to string) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		err = fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+		// Trigger Error Event, as any other send failure
+		sc.emitError(err)
+		return err
+	}
+	return sc.sendMessage(ctx, msgType, string(payloadBytes), to, "")
+}
+
+// Trigger typed Offer Event if there is a function for it
+func (sc *Client) emitOffer(payload string, from string) {
+	sc.mu.Lock()
+	f := sc.onOffer
+	sc.mu.Unlock()
+	if f == nil {
+		return
+	}
+
+	desc, err := ParseSessionDescription(payload, SDPTypeOffer)
+	if err != nil {
+		sc.emitError(err)
+		return
+	}
+	f(desc, from)
+}
+
+// Trigger typed Answer Event if there is a function for it
+func (sc *Client) emitAnswer(payload string, from string) {
+	sc.mu.Lock()
+	f := sc.onAnswer
+	sc.mu.Unlock()
+	if f == nil {
+		return
+	}
+
+	desc, err := ParseSessionDescription(payload, SDPTypeAnswer)
+	if err != nil {
+		sc.emitError(err)
+		return
+	}
+	f(desc, from)
+}
+
+// Trigger typed Candidate Event if there is a function for it
+func (sc *Client) emitCandidate(payload string, from string) {
+	sc.mu.Lock()
+	f := sc.onCandidate
+	sc.mu.Unlock()
+	if f == nil {
+		return
+	}
+
+	candidate, err := ParseIceCandidateInit(payload)
+	if err != nil {
+		sc.emitError(err)
+		return
+	}
+	f(candidate, from)
+}
@@ -0,0 +1,162 @@
+package signaling_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Testing session description parsing and validation
+func TestParseSessionDescription(t *testing.T) {
+	// Valid offer
+	desc, err := signaling.ParseSessionDescription(SDPOffer, signaling.SDPTypeOffer)
+	assert.NoError(t, err)
+	assert.Equal(t, signaling.SessionDescription{Type: signaling.SDPTypeOffer, SDP: "offer= true\nvideo= true"}, desc)
+
+	// Answer is not an offer
+	_, err = signaling.ParseSessionDescription(SDPAnswer, signaling.SDPTypeOffer)
+	assert.ErrorIs(t, err, signaling.ErrInvalidPayload)
+
+	// Not a session description
+	_, err = signaling.ParseSessionDescription("offer", signaling.SDPTypeOffer)
+	assert.ErrorIs(t, err, signaling.ErrInvalidPayload)
+
+	// Without sdp
+	_, err = signaling.ParseSessionDescription(`{"type":"answer"}`, signaling.SDPTypeAnswer)
+	assert.ErrorIs(t, err, signaling.ErrInvalidPayload)
+}
+
+// Testing ice candidate parsing and validation
+func TestParseIceCandidateInit(t *testing.T) {
+	// Valid candidate
+	candidate, err := signaling.ParseIceCandidateInit(ICECandidate)
+	assert.NoError(t, err)
+	assert.Equal(t, "upd 10.111.34.88", candidate.Candidate)
+	assert.Equal(t, "1", *candidate.SDPMid)
+	assert.Equal(t, uint16(1), *candidate.SDPMLineIndex)
+	assert.Nil(t, candidate.UsernameFragment)
+
+	// End of candidates
+	_, err = signaling.ParseIceCandidateInit(`{"candidate":""}`)
+	assert.NoError(t, err)
+
+	// Candidate without media section
+	_, err = signaling.ParseIceCandidateInit(`{"candidate":"upd 10.111.34.88"}`)
+	assert.ErrorIs(t, err, signaling.ErrInvalidPayload)
+}
+
+// Testing typed sender encodes payload
+func TestSendOffer(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket)
+
+	// Send typed offer
+	desc := signaling.SessionDescription{Type: signaling.SDPTypeOffer, SDP: "offer= true\nvideo= true"}
+	assert.NoError(t, client.SendOffer(context.Background(), desc, clientID))
+
+	// ASSERTS
+	message := sentMessage(t, ownMockWebsocket, 0)
+	assert.Equal(t, signaling.MessageType("SDP_OFFER"), message.MessageType)
+	assert.Equal(t, clientID, message.RecipientClientID)
+	assert.Equal(t, "eyJ0eXBlIjoib2ZmZXIiLCJzZHAiOiJvZmZlcj0gdHJ1ZVxudmlkZW89IHRydWUifQ==", message.MessagePayload)
+}
+
+// Testing typed sender does not send invalid payloads
+func TestSendInvalidPayload(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket)
+
+	// Error Event is triggered for every invalid payload
+	var errorEvents []error
+	client.OnError(func(err error) {
+		errorEvents = append(errorEvents, err)
+	})
+	events := client.Events()
+
+	// ASSERTS
+	answer := signaling.SessionDescription{Type: signaling.SDPTypeAnswer, SDP: "offer= true\nvideo= true"}
+	assert.ErrorIs(t, client.SendOffer(context.Background(), answer, clientID), signaling.ErrInvalidPayload)
+	assert.ErrorIs(t, client.SendCandidate(context.Background(), signaling.IceCandidateInit{Candidate: "upd 10.111.34.88"}, clientID), signaling.ErrInvalidPayload)
+	ownMockWebsocket.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	assert.Len(t, errorEvents, 2)
+	for _, err := range errorEvents {
+		assert.ErrorIs(t, err, signaling.ErrInvalidPayload)
+	}
+	assert.Len(t, events, 2)
+}
+
+// Testing typed receive functions
+func TestOnOfferAndOnCandidate(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket)
+
+	// Typed receive functions
+	offers := []signaling.SessionDescription{}
+	client.OnOffer(func(desc signaling.SessionDescription, from string) {
+		assert.Equal(t, clientID, from)
+		offers = append(offers, desc)
+	})
+	candidates := []signaling.IceCandidateInit{}
+	client.OnCandidate(func(candidate signaling.IceCandidateInit, from string) {
+		assert.Equal(t, clientID, from)
+		candidates = append(candidates, candidate)
+	})
+
+	// Raw receive function is still triggered
+	rawOffers := 0
+	client.OnSdpOffer(func(offer *string, remoteClientID *string) {
+		rawOffers++
+	})
+
+	// Receive candidate before offer
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(iceCandidateViewerMessage))
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(sdpOfferViewerMessage))
+
+	// ASSERTS
+	assert.Equal(t, 1, rawOffers)
+	assert.Equal(t, []signaling.SessionDescription{{Type: signaling.SDPTypeOffer, SDP: "offer= true\nvideo= true"}}, offers)
+	assert.Len(t, candidates, 1)
+	assert.Equal(t, "upd 10.111.34.88", candidates[0].Candidate)
+}
+
+// Testing typed receive function with invalid payload
+func TestOnOfferInvalidPayload(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket)
+
+	// Offer Event must not be triggered
+	client.OnOffer(func(desc signaling.SessionDescription, from string) {
+		t.Errorf("Unexpected offer")
+	})
+
+	// Error Event is triggered instead
+	var receivedErr error
+	client.OnError(func(err error) {
+		receivedErr = err
+	})
+
+	// Receive an answer as offer
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(`{"messageType":"SDP_OFFER","messagePayload":"eyJzZHAiOiJvZmZlcj0gdHJ1ZVxudmlkZW89IHRydWUiLCJ0eXBlIjoiYW5zd2VyIn0=","senderClientId":"TestClientId"}`))
+
+	// ASSERTS
+	assert.ErrorIs(t, receivedErr, signaling.ErrInvalidPayload)
+}
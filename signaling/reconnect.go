@@ -0,0 +1,289 @@
+package signaling
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+)
+
+// Error codes of handshakes rejected because of credentials, like expired or rotated ones
+var credentialsErrorCodes = map[string]bool{
+	"ExpiredToken":                true,
+	"ExpiredTokenException":       true,
+	"InvalidClientTokenId":        true,
+	"UnrecognizedClientException": true,
+	"InvalidSignatureException":   true,
+	"SignatureDoesNotMatch":       true,
+}
+
+// Reconnect policy for signaling client
+type ReconnectPolicy struct {
+	MaxAttempts      int           // Max reconnect attempts in a row, 0 means unlimited
+	InitialInterval  time.Duration // Wait before first reconnect attempt
+	MaxInterval      time.Duration // Upper bound for wait between attempts
+	Multiplier       float64       // Growth factor applied to wait after each attempt
+	Jitter           float64       // Randomization factor [0, 1] applied to every wait
+	ResetAfter       time.Duration // Time connection must stay open to reset attempts counter
+	FatalStatusCodes []int         // Handshake statuses stopping reconnect attempts, only 404 when empty
+}
+
+// Default reconnect policy values
+const (
+	DefaultReconnectInitialInterval = 500 * time.Millisecond
+	DefaultReconnectMaxInterval     = 30 * time.Second
+	DefaultReconnectMultiplier      = 2
+	DefaultReconnectJitter          = 0.2
+	DefaultReconnectResetAfter      = time.Minute
+)
+
+// DefaultReconnectPolicy with unlimited attempts
+func DefaultReconnectPolicy() ReconnectPolicy {
+	return ReconnectPolicy{
+		InitialInterval: DefaultReconnectInitialInterval,
+		MaxInterval:     DefaultReconnectMaxInterval,
+		Multiplier:      DefaultReconnectMultiplier,
+		Jitter:          DefaultReconnectJitter,
+		ResetAfter:      DefaultReconnectResetAfter,
+	}
+}
+
+// Backoff returns wait before reconnect attempt, attempt starts at 1
+func (p ReconnectPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	// Use defaults for empty values
+	initial := p.InitialInterval
+	if initial <= 0 {
+		initial = DefaultReconnectInitialInterval
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	// Exponential growth limited by max interval
+	backoff := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxInterval > 0 && backoff > float64(p.MaxInterval) {
+		backoff = float64(p.MaxInterval)
+	}
+
+	// Apply jitter in range [backoff*(1-jitter), backoff*(1+jitter)]
+	jitter := math.Min(math.Max(p.Jitter, 0), 1)
+	if jitter > 0 {
+		random := float64(globalMathRandomGenerator.Uint32()) / math.MaxUint32
+		backoff = backoff * (1 - jitter + 2*jitter*random)
+	}
+
+	return time.Duration(backoff)
+}
+
+// Attempts exhausted checker
+func (p ReconnectPolicy) exhausted(attempt int) bool {
+	return p.MaxAttempts > 0 && attempt >= p.MaxAttempts
+}
+
+// Use own reconnect policy, reconnect is disabled by default
+func WithReconnectPolicy(policy ReconnectPolicy) func(*Client) {
+	return func(sc *Client) {
+		sc.reconnectPolicy = &policy
+	}
+}
+
+// On Reconnecting Event Function, triggered before waiting for each attempt
+func (sc *Client) OnReconnecting(f func(attempt int, delay time.Duration)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.onReconnecting = f
+}
+
+// On Reconnected Event Function, triggered instead of Open Event after reconnect
+func (sc *Client) OnReconnected(f func(attempt int)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.onReconnected = f
+}
+
+// Reconnect policy in use, a single attempt when reconnect is disabled
+func (sc *Client) activeReconnectPolicy() ReconnectPolicy {
+	if sc.reconnectPolicy == nil {
+		return ReconnectPolicy{MaxAttempts: 1}
+	}
+	return *sc.reconnectPolicy
+}
+
+// Connect again after service asked for it, in parallel when possible
+func (sc *Client) reconnectGracefully() {
+	// New connection in parallel, so there is no signaling gap
+	if sc.wsClientFactory != nil {
+		go func() {
+			// Parallel connection failed, current one is closed and reconnected instead
+			if err := sc.refreshConnectionAttempt(true); err != nil {
+				sc.closeGoingAway()
+			}
+		}()
+		return
+	}
+
+	// Own websocket client must be closed before it is dialed again
+	go sc.closeGoingAway()
+}
+
+// Close current connection after go away message, it is reconnected when its Close Event arrives
+func (sc *Client) closeGoingAway() {
+	sc.mu.Lock()
+	// Closed meanwhile, nothing to reconnect
+	if sc.readyState != StateOpen {
+		sc.mu.Unlock()
+		return
+	}
+	sc.goingAway = true
+	sc.mu.Unlock()
+
+	sc.currentWebsocket().Close()
+}
+
+// Schedule next reconnect attempt or close when attempts are exhausted
+func (sc *Client) scheduleReconnect(immediate bool) {
+	sc.mu.Lock()
+
+	// Closed meanwhile, nothing to reconnect
+	if sc.readyState != StateOpen && sc.readyState != StateConnecting {
+		sc.mu.Unlock()
+		return
+	}
+
+	policy := sc.activeReconnectPolicy()
+
+	// No more attempts, signaling client goes offline
+	if policy.exhausted(sc.reconnectAttempt) {
+		sc.reconnectAttempt = 0
+		old, _ := sc.transitionLocked(StateClosed, StateOpen, StateConnecting)
+		sc.mu.Unlock()
+		sc.emitStateChange(old, StateClosed)
+		sc.emitError(ErrReconnectExhausted)
+		sc.emitClose()
+		return
+	}
+
+	// Next attempt
+	sc.reconnectAttempt++
+	attempt := sc.reconnectAttempt
+	delay := policy.Backoff(attempt)
+	if immediate {
+		delay = 0
+	}
+	// Attempt is pending from now on, its timer starts after Reconnecting Event so it can be cancelled from it
+	sc.reconnectSeq++
+	seq := sc.reconnectSeq
+	sc.reconnectPending = seq
+	// Connection lost, it is already CONNECTING after a failed attempt
+	old, changed := sc.transitionLocked(StateConnecting, StateOpen)
+	sc.mu.Unlock()
+
+	if changed {
+		sc.emitStateChange(old, StateConnecting)
+	}
+
+	// Trigger Reconnecting Event
+	sc.emitReconnecting(attempt, delay)
+
+	// Start waiting if nobody cancelled the attempt
+	sc.mu.Lock()
+	if sc.reconnectPending == seq {
+		sc.reconnectTimer = time.AfterFunc(delay, func() {
+			sc.startReconnect(seq)
+		})
+	}
+	sc.mu.Unlock()
+}
+
+// Run pending reconnect attempt, nothing to do if it was cancelled or already started
+func (sc *Client) startReconnect(seq uint64) {
+	sc.mu.Lock()
+	if sc.reconnectPending != seq {
+		sc.mu.Unlock()
+		return
+	}
+	sc.reconnectPending = 0
+	sc.reconnectTimer = nil
+	sc.connectGen++
+	gen := sc.connectGen
+	sc.mu.Unlock()
+
+	_ = sc.connect(context.Background(), gen)
+}
+
+// Stop pending reconnect attempt, return true if there was one. Lock must be held by caller
+func (sc *Client) stopReconnectLocked() bool {
+	sc.reconnectAttempt = 0
+
+	if sc.reconnectPending == 0 {
+		return false
+	}
+	sc.reconnectPending = 0
+	if sc.reconnectTimer != nil {
+		sc.reconnectTimer.Stop()
+		sc.reconnectTimer = nil
+	}
+	return true
+}
+
+// Default signer got new credentials, pending reconnect attempt does not wait for them when last one
+// failed because of credentials. Other failures keep their backoff
+func (sc *Client) handleCredentialsRotated(accessKeyID string, expires time.Time) {
+	sc.mu.Lock()
+	// Attempt starts once even if its timer already fired
+	if sc.reconnectTimer != nil && sc.credentialsRejected {
+		sc.reconnectTimer.Reset(0)
+	}
+	sc.mu.Unlock()
+
+	sc.emitCredentialsRotated(accessKeyID, expires)
+}
+
+// Expire default signer credentials after a handshake rejected because of them, return true
+// if they were expired so a new connection attempt may succeed with refreshed ones
+func (sc *Client) refreshCredentials(err error) bool {
+	if sc.expireCredentials == nil || !isCredentialsRejection(err) {
+		return false
+	}
+	sc.expireCredentials()
+	return true
+}
+
+// Handshake forbidden because of credentials, clock skew is not a credentials problem
+func isCredentialsRejection(err error) bool {
+	var handshakeErr *HandshakeError
+	if !errors.As(err, &handshakeErr) || handshakeErr.StatusCode != http.StatusForbidden || errors.Is(err, ErrClockSkew) {
+		return false
+	}
+	return credentialsErrorCodes[handshakeErr.Code()]
+}
+
+// Handshake answered by signaling service with a fatal status, like a wrong channel ARN, so
+// reconnect attempts stop. Other statuses, like a forbidden one, may be transient
+func (sc *Client) isRejected(err error) bool {
+	return isRejectedBy(err, sc.activeReconnectPolicy().FatalStatusCodes)
+}
+
+// Handshake answered with one of input fatal statuses, only 404 when there are none
+func isRejectedBy(err error, fatalStatusCodes []int) bool {
+	var handshakeErr *HandshakeError
+	if !errors.As(err, &handshakeErr) {
+		return false
+	}
+
+	if len(fatalStatusCodes) == 0 {
+		return handshakeErr.StatusCode == http.StatusNotFound
+	}
+	for _, code := range fatalStatusCodes {
+		if handshakeErr.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,507 @@
+package signaling_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Testing exponential backoff without jitter
+func TestReconnectPolicyBackoff(t *testing.T) {
+	// Policy without randomization
+	policy := signaling.ReconnectPolicy{
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     time.Second,
+		Multiplier:      2,
+	}
+
+	// ASSERTS
+	assert.Equal(t, 100*time.Millisecond, policy.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.Backoff(2))
+	assert.Equal(t, 400*time.Millisecond, policy.Backoff(3))
+	assert.Equal(t, 800*time.Millisecond, policy.Backoff(4))
+	assert.Equal(t, time.Second, policy.Backoff(5))
+	assert.Equal(t, time.Second, policy.Backoff(50))
+}
+
+// Testing exponential backoff with jitter
+func TestReconnectPolicyBackoffJitter(t *testing.T) {
+	// Policy with 50% randomization
+	policy := signaling.ReconnectPolicy{
+		InitialInterval: 100 * time.Millisecond,
+		Multiplier:      2,
+		Jitter:          0.5,
+	}
+
+	// Backoff always between limits
+	for i := 0; i < 100; i++ {
+		backoff := policy.Backoff(2)
+		assert.GreaterOrEqual(t, backoff, 100*time.Millisecond)
+		assert.LessOrEqual(t, backoff, 300*time.Millisecond)
+	}
+}
+
+// Testing reconnect after websocket is closed by remote
+func TestReconnectAfterUnexpectedClose(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create channel for control flow
+	c := make(chan string)
+
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+	// Create mock WebSocket
+	ownMockWebsocket := &mockWebSocket{}
+	// Expected mock WebSocket functions
+	ownMockWebsocket.On("Dial").Return(nil)
+	ownMockWebsocket.On("SetURL", mock.Anything).Return(nil)
+	ownMockWebsocket.On("OnMessage", mock.Anything, mock.Anything).Return()
+	ownMockWebsocket.On("Close").Return()
+
+	// New Signaling with mock and reconnect policy
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner),
+		signaling.WithWebsocketClient(ownMockWebsocket),
+		signaling.WithReconnectPolicy(signaling.ReconnectPolicy{InitialInterval: time.Millisecond}))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// if open event, remote closes connection
+	client.OnOpen(func() {
+		ownMockWebsocket.onClose()
+	})
+
+	// if close event, it should never happen
+	client.OnClose(func() {
+		t.Errorf("Unexpected close")
+	})
+
+	// if reconnecting event
+	client.OnReconnecting(func(attempt int, delay time.Duration) {
+		assert.Equal(t, 1, attempt)
+		assert.Equal(t, time.Millisecond, delay)
+	})
+
+	// if reconnected event
+	client.OnReconnected(func(attempt int) {
+		assert.Equal(t, 1, attempt)
+		// URL is signed again for every connection
+		ownMockSigner.AssertNumberOfCalls(t, "GetSignedURL", 2)
+		ownMockWebsocket.AssertNumberOfCalls(t, "Dial", 2)
+		c <- "done"
+	})
+
+	// Signaling Open Connection
+	err = client.Open()
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Wait until done
+	if <-c != "done" {
+		t.Errorf("Unexpected error")
+	}
+}
+
+// Testing signaling client goes CLOSED when attempts are exhausted
+func TestReconnectExhausted(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create channel for control flow
+	c := make(chan string)
+
+	// Create mock Signer, it only works first time
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil).Once()
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("MockError"))
+
+	// Create mock WebSocket
+	ownMockWebsocket := &mockWebSocket{}
+	// Expected mock WebSocket functions
+	ownMockWebsocket.On("Dial").Return(nil)
+	ownMockWebsocket.On("SetURL", mock.Anything).Return(nil)
+	ownMockWebsocket.On("OnMessage", mock.Anything, mock.Anything).Return()
+	ownMockWebsocket.On("Close").Return()
+
+	// New Signaling with mock and reconnect policy
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner),
+		signaling.WithWebsocketClient(ownMockWebsocket),
+		signaling.WithReconnectPolicy(signaling.ReconnectPolicy{InitialInterval: time.Millisecond, MaxAttempts: 2}))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Attempts counter
+	attempts := 0
+
+	// if open event, remote closes connection
+	client.OnOpen(func() {
+		ownMockWebsocket.onClose()
+	})
+
+	// if reconnecting event
+	client.OnReconnecting(func(attempt int, delay time.Duration) {
+		attempts = attempt
+	})
+
+	// if error event
+	client.OnError(func(err error) {
+		if errors.Is(err, signaling.ErrReconnectExhausted) {
+			assert.Equal(t, 2, attempts)
+		}
+	})
+
+	// if close event
+	client.OnClose(func() {
+		ownMockSigner.AssertNumberOfCalls(t, "GetSignedURL", 3)
+		c <- "done"
+	})
+
+	// Signaling Open Connection
+	err = client.Open()
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Wait until done
+	if <-c != "done" {
+		t.Errorf("Unexpected error")
+	}
+}
+
+// Testing Close cancels pending reconnect attempt
+func TestReconnectCancelledByClose(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create channel for control flow
+	c := make(chan string)
+
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+	// Create mock WebSocket
+	ownMockWebsocket := &mockWebSocket{}
+	// Expected mock WebSocket functions
+	ownMockWebsocket.On("Dial").Return(nil)
+	ownMockWebsocket.On("SetURL", mock.Anything).Return(nil)
+	ownMockWebsocket.On("OnMessage", mock.Anything, mock.Anything).Return()
+	ownMockWebsocket.On("Close").Return()
+
+	// New Signaling with mock and a long reconnect wait
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner),
+		signaling.WithWebsocketClient(ownMockWebsocket),
+		signaling.WithReconnectPolicy(signaling.ReconnectPolicy{InitialInterval: time.Hour}))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// if open event, remote closes connection
+	client.OnOpen(func() {
+		ownMockWebsocket.onClose()
+	})
+
+	// if reconnecting event, give up
+	client.OnReconnecting(func(attempt int, delay time.Duration) {
+		client.Close()
+	})
+
+	// if close event
+	client.OnClose(func() {
+		ownMockSigner.AssertNumberOfCalls(t, "GetSignedURL", 1)
+		ownMockWebsocket.AssertNotCalled(t, "Close")
+		c <- "done"
+	})
+
+	// Signaling Open Connection
+	err = client.Open()
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Wait until done
+	if <-c != "done" {
+		t.Errorf("Unexpected error")
+	}
+}
+
+// Testing go away message reconnects without reconnect policy when parallel connection fails
+func TestGoAwayParallelConnectionFails(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create channel for control flow
+	c := make(chan string)
+
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+	// First mock WebSocket, dialed again by reconnect
+	ownMockWebsocket := newMockWebSocket()
+
+	// Mock WebSocket factory, parallel connection fails
+	created := 0
+	factory := func() signaling.WebSocketClientI {
+		created++
+		if created == 1 {
+			return ownMockWebsocket
+		}
+		ws := &silentWebSocket{&mockWebSocket{}}
+		ws.On("Dial").Return(errors.New("MockError"))
+		ws.On("SetURL", mock.Anything).Return(nil)
+		ws.On("OnMessage", mock.Anything, mock.Anything).Return()
+		return ws
+	}
+
+	// New Signaling with mock factory
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner), signaling.WithWebsocketClientFactory(factory))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// if open event, service asks for a new connection
+	client.OnOpen(func() {
+		ownMockWebsocket.onMessage(signaling.TextMessage, []byte(goAwayMessage))
+	})
+
+	// if error event
+	client.OnError(func(err error) {})
+
+	// if close event, it should never happen
+	client.OnClose(func() {
+		t.Errorf("Unexpected close")
+	})
+
+	// if reconnected event
+	client.OnReconnected(func(attempt int) {
+		assert.Equal(t, 1, attempt)
+		ownMockWebsocket.AssertNumberOfCalls(t, "Close", 1)
+		ownMockSigner.AssertNumberOfCalls(t, "GetSignedURL", 3)
+		c <- "done"
+	})
+
+	// Signaling Open Connection
+	err = client.Open()
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Wait until done
+	select {
+	case <-c:
+	case <-time.After(time.Second):
+		t.Fatalf("Signaling client was not reconnected")
+	}
+	assert.Equal(t, signaling.StateOpen, client.State())
+}
+
+// Testing Close from Reconnecting Event cancels an immediate reconnect attempt
+func TestReconnectImmediateCancelledByClose(t *testing.T) {
+	// Timer of immediate attempt may fire at once, try several times
+	for i := 0; i < 20; i++ {
+		// Load Initial values
+		InitInfo()
+
+		// Create mock Signer
+		ownMockSigner := &mockSigner{}
+		// Expected GetSignedURL function
+		ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+		// Create mock WebSocket
+		ownMockWebsocket := newMockWebSocket()
+
+		// New Signaling with mock
+		client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner), signaling.WithWebsocketClient(ownMockWebsocket))
+
+		// if something wrong happened
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		// if open event, service asks for a new connection
+		client.OnOpen(func() {
+			ownMockWebsocket.onMessage(signaling.TextMessage, []byte(goAwayMessage))
+		})
+
+		// if reconnecting event, give up
+		reconnecting := make(chan string, 1)
+		client.OnReconnecting(func(attempt int, delay time.Duration) {
+			assert.Equal(t, time.Duration(0), delay)
+			client.Close()
+			reconnecting <- "done"
+		})
+
+		// Signaling Open Connection
+		err = client.Open()
+
+		// if something wrong happened
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		// ASSERTS
+		select {
+		case <-reconnecting:
+		case <-time.After(time.Second):
+			t.Fatalf("Signaling client did not reconnect")
+		}
+		time.Sleep(5 * time.Millisecond)
+		ownMockSigner.AssertNumberOfCalls(t, "GetSignedURL", 1)
+		assert.Equal(t, signaling.StateClosed, client.State())
+	}
+}
+
+// Session credentials provider with a new access key on every retrieve, last retrieve waits for test
+type gatedProvider struct {
+	credentials.Expiry
+	mu       sync.Mutex
+	ttls     []time.Duration // Lifetime of credentials of each retrieve
+	release  chan struct{}   // Closed when last retrieve may finish
+	retrieve int
+}
+
+// Retrieve next credentials
+func (p *gatedProvider) Retrieve() (credentials.Value, error) {
+	p.mu.Lock()
+	p.retrieve++
+	retrieve := p.retrieve
+	p.mu.Unlock()
+	if retrieve >= len(p.ttls) {
+		<-p.release
+		retrieve = len(p.ttls)
+	}
+
+	p.SetExpiration(time.Now().Add(p.ttls[retrieve-1]), 0)
+	return credentials.Value{AccessKeyID: fmt.Sprintf("ASIAKEY%d", retrieve), SecretAccessKey: "SECRET", SessionToken: "TOKEN"}, nil
+}
+
+// Mock WebSocket opening only dials that succeed, without Close Event
+type rejectingWebSocket struct {
+	*mockWebSocket
+}
+
+// Mock Websocket Dial Function, Open Event only when it succeeds
+func (m *rejectingWebSocket) Dial() error {
+	args := m.Called()
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	m.onOpen()
+	return nil
+}
+
+// Mock Websocket Close Function without Close Event
+func (m *rejectingWebSocket) Close() {
+	m.Called()
+}
+
+// Testing rotated credentials skip backoff of pending reconnect attempt only when last one was rejected because of credentials
+func TestReconnectCredentialsRotated(t *testing.T) {
+	expired := &signaling.HandshakeError{StatusCode: 403, Header: http.Header{"X-Amzn-Errortype": {"ExpiredTokenException:"}}}
+
+	tests := []struct {
+		name      string
+		failure   error           // Error of failed dials
+		failures  int             // Dials failing after first connection is lost
+		ttls      []time.Duration // Lifetime of credentials of each retrieve
+		restarted bool            // Pending attempt starts when credentials are rotated
+	}{
+		// First attempt is signed again with credentials expiring soon, whose refresh rotates them
+		{name: "credentials rejected", failure: expired, failures: 2, ttls: []time.Duration{time.Hour, time.Minute, time.Hour}, restarted: true},
+		// Credentials of first connection expire soon, their refresh rotates them
+		{name: "service unavailable", failure: &signaling.HandshakeError{StatusCode: 503}, failures: 1, ttls: []time.Duration{time.Minute, time.Hour}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Load Initial values
+			InitInfo()
+
+			// Create mock WebSocket, dials fail after first connection until backoff
+			ownMockWebsocket := &rejectingWebSocket{&mockWebSocket{}}
+			ownMockWebsocket.On("Dial").Return(nil).Once()
+			ownMockWebsocket.On("Dial").Return(tt.failure).Times(tt.failures)
+			ownMockWebsocket.On("Dial").Return(nil)
+			ownMockWebsocket.On("SetURL", mock.Anything).Return(nil)
+			ownMockWebsocket.On("OnMessage", mock.Anything, mock.Anything).Return()
+			ownMockWebsocket.On("Close").Return()
+
+			// New Signaling with default signer and a long backoff for second attempt
+			provider := &gatedProvider{ttls: tt.ttls, release: make(chan struct{})}
+			client, err := signaling.New(&configMaster, signaling.WithCredentialsProvider(provider),
+				signaling.WithWebsocketClient(ownMockWebsocket),
+				signaling.WithReconnectPolicy(signaling.ReconnectPolicy{InitialInterval: 10 * time.Millisecond, Multiplier: 1e6}))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer client.Close()
+
+			// Second attempt waits for its backoff
+			waiting := make(chan struct{})
+			client.OnReconnecting(func(attempt int, delay time.Duration) {
+				if attempt == 2 {
+					close(waiting)
+				}
+			})
+			reconnected := make(chan int, 1)
+			client.OnReconnected(func(attempt int) {
+				reconnected <- attempt
+			})
+
+			// if open event, remote closes connection
+			client.OnOpen(func() {
+				ownMockWebsocket.onClose()
+			})
+
+			// Signaling Open Connection
+			assert.NoError(t, client.Open())
+
+			// Credentials are rotated once second attempt timer is started
+			<-waiting
+			time.Sleep(20 * time.Millisecond)
+			close(provider.release)
+
+			// ASSERTS
+			select {
+			case attempt := <-reconnected:
+				assert.True(t, tt.restarted, "Unexpected reconnect before backoff")
+				assert.Equal(t, 2, attempt)
+			case <-time.After(200 * time.Millisecond):
+				assert.False(t, tt.restarted, "Pending attempt did not start")
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package signaling
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Part of max connection age kept for retrying a failed refresh, first attempt starts when it begins
+const connectionRefreshMarginDivisor = 5
+
+// Open a fresh connection before current one reaches max age, signaling service
+// terminates long lived connections and presigned urls are only valid for a few minutes.
+// The new connection is opened in parallel and swapped in before the old one is closed, so
+// own websocket clients need a websocket client factory too, New fails without it, see WithWebsocketClientFactory.
+// Refresh starts when a fifth of max age is left, failed attempts are tried again with
+// reconnect policy backoff while the connection is younger than max age
+func WithConnectionRefresh(maxAge time.Duration) func(*Client) {
+	return func(sc *Client) {
+		sc.maxConnectionAge = maxAge
+	}
+}
+
+// ConnectionAge returns how long current websocket connection has been open, 0 if it is not open
+func (sc *Client) ConnectionAge() time.Duration {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.readyState != StateOpen {
+		return 0
+	}
+	return time.Since(sc.openedAt)
+}
+
+// Start timer for next connection refresh
+func (sc *Client) scheduleRefresh() {
+	// Refresh disabled
+	if sc.maxConnectionAge <= 0 {
+		return
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.refreshTimer != nil {
+		sc.refreshTimer.Stop()
+	}
+	// New connection, no failed attempts yet
+	sc.refreshAttempt = 0
+	sc.refreshTimer = time.AfterFunc(sc.maxConnectionAge-sc.maxConnectionAge/connectionRefreshMarginDivisor, sc.refreshConnection)
+}
+
+// Schedule refresh again after a failed attempt, unless current connection reaches max age before it
+func (sc *Client) retryRefresh(err error) {
+	// Signaling service rejected it for good
+	if sc.isRejected(err) {
+		return
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	// Closed meanwhile, nothing to refresh
+	if sc.readyState != StateOpen {
+		return
+	}
+
+	policy := DefaultReconnectPolicy()
+	if sc.reconnectPolicy != nil {
+		policy = *sc.reconnectPolicy
+	}
+	sc.refreshAttempt++
+	delay := policy.Backoff(sc.refreshAttempt)
+
+	// Too late, connection is reconnected when signaling service terminates it
+	if time.Since(sc.openedAt)+delay >= sc.maxConnectionAge {
+		return
+	}
+
+	if sc.refreshTimer != nil {
+		sc.refreshTimer.Stop()
+	}
+	sc.refreshTimer = time.AfterFunc(delay, sc.refreshConnection)
+}
+
+// Stop pending connection refresh
+func (sc *Client) stopRefresh() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.refreshTimer != nil {
+		sc.refreshTimer.Stop()
+		sc.refreshTimer = nil
+	}
+}
+
+// Open a new signed connection in parallel to current one, tried again when it fails
+func (sc *Client) refreshConnection() {
+	if err := sc.refreshConnectionAttempt(true); err != nil {
+		sc.retryRefresh(err)
+	}
+}
+
+// Sign and dial new connection, a handshake rejected because of clock skew or credentials is tried
+// again once with corrected clock or refreshed credentials
+func (sc *Client) refreshConnectionAttempt(retry bool) error {
+	sc.mu.Lock()
+	sc.refreshTimer = nil
+	isOpen := sc.readyState == StateOpen
+	gen := sc.connectGen
+	sc.mu.Unlock()
+
+	// Nothing to refresh
+	if !isOpen {
+		return nil
+	}
+
+	// Sign again, old url is expired for sure
+	signedURL, err := sc.signURL()
+
+	// if something wrong happened, current connection is still alive
+	if err != nil {
+		sc.emitError(err)
+		return err
+	}
+
+	// Dial new websocket client, it replaces current one when it is open
+	ws := sc.wsClientFactory()
+	err = sc.dial(context.Background(), gen, ws, signedURL, func() {
+		sc.swapConnection(ws)
+	})
+
+	// Signed again with corrected clock or refreshed credentials
+	if err != nil && retry && (sc.correctClockSkew(context.Background(), err) || sc.refreshCredentials(err)) {
+		return sc.refreshConnectionAttempt(false)
+	}
+
+	// if something wrong happened, current connection is still alive
+	if err != nil {
+		sc.emitError(err)
+	}
+	return err
+}
+
+// Replace current connection by a new open one and close the old one
+func (sc *Client) swapConnection(ws WebSocketClientI) {
+	sc.mu.Lock()
+
+	// Closed meanwhile, new connection is not needed
+	if sc.readyState != StateOpen {
+		sc.mu.Unlock()
+		ws.Close()
+		return
+	}
+
+	old := sc.wsClient
+	oldSends := sc.inFlightSends
+	sc.wsClient = ws
+	sc.inFlightSends = &sync.WaitGroup{}
+	sc.openedAt = time.Now()
+	sc.mu.Unlock()
+
+	// Next refresh for new connection
+	sc.scheduleRefresh()
+
+	// New sends go over new connection, old one is closed after its in flight sends without
+	// holding new connection, that may be opening yet
+	go func() {
+		oldSends.Wait()
+
+		// Status responses for messages sent over old connection will never arrive
+		sc.failDeliveriesSentOn(old, ErrDeliveryUnconfirmed)
+
+		// Old connection is not current anymore, so its close event is ignored
+		old.Close()
+	}()
+}
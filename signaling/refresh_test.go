@@ -0,0 +1,443 @@
+package signaling_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// New mock WebSocket with all expected functions
+func newMockWebSocket() *mockWebSocket {
+	ownMockWebsocket := &mockWebSocket{}
+	ownMockWebsocket.On("Dial").Return(nil)
+	ownMockWebsocket.On("SetURL", mock.Anything).Return(nil)
+	ownMockWebsocket.On("OnMessage", mock.Anything, mock.Anything).Return()
+	ownMockWebsocket.On("Close").Return()
+	ownMockWebsocket.On("Send", mock.Anything, mock.Anything).Return(nil)
+	return ownMockWebsocket
+}
+
+// Testing connection is replaced when it reaches max age
+func TestConnectionRefresh(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create channel for control flow
+	c := make(chan string)
+
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+	// Create channel for first connection close
+	firstClosed := make(chan string, 1)
+
+	// Number of created mock WebSocket
+	var mu sync.Mutex
+	created := 0
+
+	// Mock WebSocket factory
+	factory := func() signaling.WebSocketClientI {
+		mu.Lock()
+		defer mu.Unlock()
+		created++
+		// First connection notifies when it is closed
+		if created == 1 {
+			ws := &mockWebSocket{}
+			ws.On("Dial").Return(nil)
+			ws.On("SetURL", mock.Anything).Return(nil)
+			ws.On("OnMessage", mock.Anything, mock.Anything).Return()
+			ws.On("Close").Return().Run(func(args mock.Arguments) {
+				firstClosed <- "done"
+			})
+			return ws
+		}
+		return newMockWebSocket()
+	}
+
+	// New Signaling with mock factory and max connection age
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner),
+		signaling.WithWebsocketClientFactory(factory),
+		signaling.WithConnectionRefresh(50*time.Millisecond))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// if open event
+	client.OnOpen(func() {
+		c <- "done"
+	})
+
+	// if close event
+	closeEvents := make(chan string, 1)
+	client.OnClose(func() {
+		closeEvents <- "done"
+	})
+
+	// Signaling Open Connection
+	err = client.Open()
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Wait until open
+	if <-c != "done" {
+		t.Errorf("Unexpected error")
+	}
+
+	// Wait until first connection is replaced
+	select {
+	case <-firstClosed:
+	case <-time.After(time.Second):
+		t.Fatalf("First connection was not replaced")
+	}
+
+	// ASSERTS
+	mu.Lock()
+	assert.GreaterOrEqual(t, created, 2)
+	mu.Unlock()
+	assert.Greater(t, client.ConnectionAge(), time.Duration(0))
+	assert.Len(t, closeEvents, 0)
+
+	// Stop refreshing
+	client.Close()
+	<-closeEvents
+}
+
+// Testing connection refresh is rejected with own websocket client and without factory
+func TestConnectionRefreshWithoutFactory(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+
+	// New Signaling with own websocket client and max connection age
+	_, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner),
+		signaling.WithWebsocketClient(newMockWebSocket()),
+		signaling.WithConnectionRefresh(time.Millisecond))
+
+	// ASSERTS
+	assert.ErrorIs(t, err, signaling.ErrInvalidConfig)
+	var configErr *signaling.ConfigError
+	assert.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "maxConnectionAge", configErr.Field)
+
+	// Default websocket client comes with its factory
+	_, err = signaling.New(&configMaster, signaling.WithSigner(ownMockSigner), signaling.WithConnectionRefresh(time.Millisecond))
+	assert.NoError(t, err)
+}
+
+// Testing failed connection refresh is tried again while connection is younger than max age
+func TestConnectionRefreshRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int  // Failed refresh dials before one succeeds
+		replaced bool // First connection is replaced before max age
+	}{
+		{name: "retried until it succeeds", failures: 2, replaced: true},
+		{name: "given up at max age", failures: 1000, replaced: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Load Initial values
+			InitInfo()
+
+			// Create channel for control flow
+			c := make(chan string)
+
+			// Create mock Signer
+			ownMockSigner := &mockSigner{}
+			// Expected GetSignedURL function
+			ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+			// Create channel for first connection close
+			firstClosed := make(chan string, 1)
+
+			// Number of created mock WebSocket
+			var mu sync.Mutex
+			created := 0
+
+			// Mock WebSocket factory, refresh dials fail before one succeeds
+			factory := func() signaling.WebSocketClientI {
+				mu.Lock()
+				defer mu.Unlock()
+				created++
+				switch {
+				case created == 1:
+					ws := &mockWebSocket{}
+					ws.On("Dial").Return(nil)
+					ws.On("SetURL", mock.Anything).Return(nil)
+					ws.On("OnMessage", mock.Anything, mock.Anything).Return()
+					ws.On("Close").Return().Run(func(args mock.Arguments) {
+						firstClosed <- "done"
+					})
+					return ws
+				case created <= tt.failures+1:
+					ws := &silentWebSocket{&mockWebSocket{}}
+					ws.On("Dial").Return(errors.New("MockError"))
+					ws.On("SetURL", mock.Anything).Return(nil)
+					ws.On("OnMessage", mock.Anything, mock.Anything).Return()
+					return ws
+				}
+				return newMockWebSocket()
+			}
+
+			// New Signaling with mock factory, max connection age and short backoff
+			client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner),
+				signaling.WithWebsocketClientFactory(factory),
+				signaling.WithConnectionRefresh(500*time.Millisecond),
+				signaling.WithReconnectPolicy(signaling.ReconnectPolicy{
+					InitialInterval: 5 * time.Millisecond,
+					MaxInterval:     20 * time.Millisecond,
+					Multiplier:      2,
+				}))
+
+			// if something wrong happened
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+
+			// if open event
+			client.OnOpen(func() {
+				c <- "done"
+			})
+
+			// if error event
+			client.OnError(func(err error) {})
+
+			// Signaling Open Connection
+			err = client.Open()
+
+			// if something wrong happened
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+
+			// Wait until open
+			if <-c != "done" {
+				t.Errorf("Unexpected error")
+			}
+			defer client.Close()
+
+			// ASSERTS
+			select {
+			case <-firstClosed:
+				assert.True(t, tt.replaced)
+			case <-time.After(600 * time.Millisecond):
+				assert.False(t, tt.replaced)
+			}
+			mu.Lock()
+			attempts := created
+			mu.Unlock()
+			assert.GreaterOrEqual(t, attempts, 3)
+
+			// No more attempts after max age
+			if !tt.replaced {
+				time.Sleep(100 * time.Millisecond)
+				mu.Lock()
+				assert.Equal(t, attempts, created)
+				mu.Unlock()
+			}
+		})
+	}
+}
+
+// Testing tracked messages sent over replaced connection are not confirmed
+func TestConnectionRefreshFailsDeliveries(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create channel for control flow
+	c := make(chan string)
+
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+	// Create channel for first connection close
+	firstClosed := make(chan string, 1)
+
+	// Number of created mock WebSocket
+	var mu sync.Mutex
+	created := 0
+
+	// Mock WebSocket factory
+	factory := func() signaling.WebSocketClientI {
+		mu.Lock()
+		defer mu.Unlock()
+		created++
+		// First connection notifies when it is closed
+		if created == 1 {
+			ws := &mockWebSocket{}
+			ws.On("Dial").Return(nil)
+			ws.On("SetURL", mock.Anything).Return(nil)
+			ws.On("OnMessage", mock.Anything, mock.Anything).Return()
+			ws.On("Send", mock.Anything, mock.Anything).Return(nil)
+			ws.On("Close").Return().Run(func(args mock.Arguments) {
+				firstClosed <- "done"
+			})
+			return ws
+		}
+		return newMockWebSocket()
+	}
+
+	// New Signaling with mock factory, max connection age and long status response window
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner),
+		signaling.WithWebsocketClientFactory(factory),
+		signaling.WithConnectionRefresh(50*time.Millisecond),
+		signaling.WithStatusResponseWindow(time.Hour))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// if open event
+	client.OnOpen(func() {
+		c <- "done"
+	})
+
+	// Signaling Open Connection
+	err = client.Open()
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Wait until open
+	if <-c != "done" {
+		t.Errorf("Unexpected error")
+	}
+	defer client.Close()
+
+	// Send tracked offer over first connection
+	delivery := client.SendSdpOfferAsync(SDPOffer, &clientID)
+
+	// Wait until first connection is replaced
+	select {
+	case <-firstClosed:
+	case <-time.After(time.Second):
+		t.Fatalf("First connection was not replaced")
+	}
+
+	// Send tracked offer over new connection
+	next := client.SendSdpOfferAsync(SDPOffer, &clientID)
+
+	// ASSERTS
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.ErrorIs(t, delivery.Wait(ctx), signaling.ErrDeliveryUnconfirmed)
+	assert.NoError(t, next.Err())
+	select {
+	case <-next.Done():
+		t.Errorf("Unexpected delivery resolution")
+	default:
+	}
+}
+
+// Testing new connection is not held by a send in flight over replaced one
+func TestConnectionRefreshDoesNotWaitForOldSends(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// First connection send is blocked until test releases it
+	sending := make(chan struct{})
+	release := make(chan struct{})
+	firstClosed := make(chan struct{})
+	first := &mockWebSocket{}
+	first.On("Dial").Return(nil)
+	first.On("SetURL", mock.Anything).Return(nil)
+	first.On("OnMessage", mock.Anything, mock.Anything).Return()
+	first.On("Close").Return().Run(func(args mock.Arguments) {
+		close(firstClosed)
+	}).Once()
+	first.On("Send", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		close(sending)
+		<-release
+	})
+
+	// Second connection tells when its dial is done, so its messages are read
+	dialed := make(chan chan string, 1)
+	second := &mockWebSocket{}
+	second.On("Dial").Return(nil)
+	second.On("SetURL", mock.Anything).Return(nil)
+	second.On("OnMessage", mock.Anything, mock.Anything).Return().Run(func(args mock.Arguments) {
+		dialed <- args.Get(0).(chan string)
+	})
+	second.On("Close").Return()
+
+	// Mock WebSocket factory
+	created := 0
+	factory := func() signaling.WebSocketClientI {
+		created++
+		if created == 1 {
+			return first
+		}
+		return second
+	}
+
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+	// Open client
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner), signaling.WithWebsocketClientFactory(factory))
+
+	// if something wrong happened
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	assert.NoError(t, client.OpenContext(context.Background()))
+
+	// Send without deadline over first connection
+	sent := make(chan error, 1)
+	go func() {
+		sent <- client.SendSdpOfferContext(context.Background(), SDPOffer, &clientID)
+	}()
+	<-sending
+
+	// Go away replaces connection
+	first.onMessage(signaling.TextMessage, []byte(goAwayMessage))
+
+	// ASSERTS
+	select {
+	case dial := <-dialed:
+		select {
+		case <-dial:
+		case <-time.After(time.Second):
+			t.Fatalf("New connection was held by send over old one")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Connection was not replaced")
+	}
+	select {
+	case <-firstClosed:
+		t.Fatalf("Old connection closed before its send was over")
+	default:
+	}
+
+	// Old connection is closed once its send is over
+	close(release)
+	assert.NoError(t, <-sent)
+	select {
+	case <-firstClosed:
+	case <-time.After(time.Second):
+		t.Fatalf("Old connection was not closed")
+	}
+}
@@ -0,0 +1,26 @@
+package signaling
+
+import "context"
+
+// Sender signaling Sdp Offer Messages returning send error
+func (sc *Client) SendSdpOfferContext(ctx context.Context, sdpOfferMsg string, recipientClientID *string) error {
+	return sc.sendMessage(ctx, sdpOffer, sdpOfferMsg, recipientClientIDValue(recipientClientID), "")
+}
+
+// Sender signaling Sdp Answer Messages returning send error
+func (sc *Client) SendSdpAnswerContext(ctx context.Context, sdpAnswerMsg string, recipientClientID *string) error {
+	return sc.sendMessage(ctx, sdpAnswer, sdpAnswerMsg, recipientClientIDValue(recipientClientID), "")
+}
+
+// Sender signaling Ice Candidate Messages returning send error
+func (sc *Client) SendIceCandidateContext(ctx context.Context, iceCandidateMsg string, recipientClientID *string) error {
+	return sc.sendMessage(ctx, iceCandidate, iceCandidateMsg, recipientClientIDValue(recipientClientID), "")
+}
+
+// Get client Id if exists
+func recipientClientIDValue(recipientClientID *string) string {
+	if recipientClientID == nil {
+		return ""
+	}
+	return *recipientClientID
+}
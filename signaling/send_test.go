@@ -0,0 +1,200 @@
+package signaling_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Testing send with context returns nil when message is sent
+func TestSendSdpOfferContext(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket)
+
+	// ASSERTS
+	assert.NoError(t, client.SendSdpOfferContext(context.Background(), SDPOffer, &clientID))
+	ownMockWebsocket.AssertCalled(t, "Send", signaling.TextMessage, []byte(sdpOfferMaster))
+}
+
+// Testing send with context when connection is not open and there is no Error Event function
+func TestSendSdpAnswerContextNotOpen(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// New Signaling
+	client, err := signaling.New(&configMaster, signaling.WithWebsocketClient(newMockWebSocket()))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// ASSERTS
+	err = client.SendSdpAnswerContext(context.Background(), SDPAnswer, &clientID)
+	assert.ErrorIs(t, err, signaling.ErrNotOpen)
+}
+
+// Testing send with context as viewer with recipient client id
+func TestSendIceCandidateContextUnexpectedRecipient(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create channel for control flow
+	c := make(chan string)
+
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+	// Create mock WebSocket
+	ownMockWebsocket := newMockWebSocket()
+
+	// New Signaling with mock
+	client, err := signaling.New(&configViewer, signaling.WithSigner(ownMockSigner), signaling.WithWebsocketClient(ownMockWebsocket))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Error Event is still triggered
+	errorEvents := 0
+	client.OnError(func(err error) {
+		errorEvents++
+	})
+
+	// if open event
+	client.OnOpen(func() {
+		err := client.SendIceCandidateContext(context.Background(), ICECandidate, &clientID)
+		assert.ErrorIs(t, err, signaling.ErrUnexpectedRecipient)
+		assert.Equal(t, 1, errorEvents)
+		ownMockWebsocket.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+		c <- "done"
+	})
+
+	// Signaling Open Connection
+	err = client.Open()
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Wait until done
+	if <-c != "done" {
+		t.Errorf("Unexpected error")
+	}
+}
+
+// Testing send with context when websocket client can not write
+func TestSendSdpOfferContextWriteError(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create mock WebSocket failing on send
+	writeErr := errors.New("MockError")
+	ownMockWebsocket := &mockWebSocket{}
+	ownMockWebsocket.On("Dial").Return(nil)
+	ownMockWebsocket.On("SetURL", mock.Anything).Return(nil)
+	ownMockWebsocket.On("OnMessage", mock.Anything, mock.Anything).Return()
+	ownMockWebsocket.On("Send", mock.Anything, mock.Anything).Return(writeErr)
+
+	// Open client
+	client := openMasterWithMock(t, ownMockWebsocket)
+
+	// ASSERTS
+	err := client.SendSdpOfferContext(context.Background(), SDPOffer, &clientID)
+	assert.ErrorIs(t, err, signaling.ErrSendFailed)
+	assert.ErrorIs(t, err, writeErr)
+}
+
+// Testing send with cancelled context
+func TestSendSdpOfferContextCancelled(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket)
+
+	// Cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// ASSERTS
+	assert.ErrorIs(t, client.SendSdpOfferContext(ctx, SDPOffer, &clientID), context.Canceled)
+	ownMockWebsocket.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+// Testing send hitting its write deadline closes broken connection, so client reconnects
+func TestSendContextWriteDeadlineReconnects(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Signaling service stand-in never reading messages, it keeps connections so they are not collected
+	var mu sync.Mutex
+	var conns []*websocket.Conn
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		conns = append(conns, conn)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	// Signaling client with reconnect
+	client := newStandInClient(t, server, signaling.WithReconnectPolicy(signaling.ReconnectPolicy{InitialInterval: time.Millisecond}))
+	defer client.Close()
+
+	// if reconnected event
+	reconnected := make(chan int, 1)
+	client.OnReconnected(func(attempt int) {
+		reconnected <- attempt
+	})
+
+	// Signaling Open Connection
+	assert.NoError(t, client.OpenContext(context.Background()))
+
+	// Send until socket buffers are full, so a write blocks until its deadline
+	var err error
+	for i := 0; i < 1000 && err == nil; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		err = client.SendSdpOfferContext(ctx, strings.Repeat("v", 256*1024), &clientID)
+		cancel()
+	}
+
+	// ASSERTS
+	assert.ErrorIs(t, err, signaling.ErrSendFailed)
+	var transportErr *signaling.TransportError
+	assert.ErrorAs(t, err, &transportErr)
+	select {
+	case attempt := <-reconnected:
+		assert.Equal(t, 1, attempt)
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Signaling client did not reconnect")
+	}
+	assert.Equal(t, signaling.StateOpen, client.State())
+	assert.NoError(t, client.SendSdpOfferContext(context.Background(), SDPOffer, &clientID))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, conns, 2)
+}
@@ -0,0 +1,246 @@
+package signaling
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Default idle time before a viewer session that was not answered expires
+const DefaultSessionIdleTimeout = 2 * time.Minute
+
+// Default idle time before an answered viewer session that is not connected expires, longer
+// because an answered viewer may be quiet while its media path comes up
+const DefaultAnsweredSessionIdleTimeout = 10 * time.Minute
+
+// Error when a viewer session does not exist
+var errNoSession = errors.New("there is no session for viewer")
+
+// Viewer session State Type
+type SessionState string
+
+const (
+	// Offer received from viewer
+	SessionOfferReceived SessionState = "OFFER_RECEIVED"
+	// Answer sent to viewer
+	SessionAnswered SessionState = "ANSWERED"
+	// Viewer peer connection is connected, see MarkConnected
+	SessionConnected SessionState = "CONNECTED"
+	// Viewer session ended or expired
+	SessionGone SessionState = "GONE"
+)
+
+// Viewer session of a master signaling client
+type Session struct {
+	ClientID     string       // Viewer client id
+	State        SessionState // Viewer session state
+	CreatedAt    time.Time    // Offer from viewer that created it
+	LastActivity time.Time    // Last message from or to viewer
+}
+
+// Viewer session with its idle timer
+type session struct {
+	Session
+	timer *time.Timer // Idle timer, stopped while connected
+}
+
+// Use max concurrent viewers, signaling channels allow up to 10 viewers. When there is no room, sessions
+// not connected and idle for session idle timeout are ended first, otherwise offers from other viewers
+// are dropped with an ErrTooManyViewers Error Event. Unlimited by default
+func WithMaxViewers(maxViewers int) func(*Client) {
+	return func(sc *Client) {
+		sc.maxViewers = maxViewers
+	}
+}
+
+// Use own idle time before a viewer session that was not answered expires, 0 disables expiration.
+// Connected sessions last until EndSession
+func WithSessionIdleTimeout(timeout time.Duration) func(*Client) {
+	return func(sc *Client) {
+		sc.sessionIdleTimeout = timeout
+	}
+}
+
+// Use own idle time before an answered viewer session that is not connected expires, 0 disables expiration
+func WithAnsweredSessionIdleTimeout(timeout time.Duration) func(*Client) {
+	return func(sc *Client) {
+		sc.answeredSessionIdleTimeout = timeout
+	}
+}
+
+// On Session Change Event Function, triggered when a viewer session is created or its state changes
+func (sc *Client) OnSessionChange(f func(session Session)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.onSessionChange = f
+}
+
+// Sessions returns active viewer sessions sorted by client id, only masters have viewer sessions
+func (sc *Client) Sessions() []Session {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	sessions := make([]Session, 0, len(sc.sessions))
+	for _, s := range sc.sessions {
+		sessions = append(sessions, s.Session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ClientID < sessions[j].ClientID
+	})
+	return sessions
+}
+
+// MarkConnected tells viewer peer connection is connected, so its session does not expire.
+// It returns false if there is no session for viewer
+func (sc *Client) MarkConnected(clientID string) bool {
+	if sc.config.Role != Master {
+		return false
+	}
+	return sc.updateSession(clientID, SessionConnected, false) == nil
+}
+
+// EndSession removes viewer session and frees its pending ice candidates, call it when viewer peer connection is over
+func (sc *Client) EndSession(clientID string) {
+	sc.mu.Lock()
+	s := sc.endSessionLocked(clientID)
+	sc.mu.Unlock()
+
+	if s != nil {
+		sc.emitSessionChange(s.Session)
+	}
+}
+
+// Register activity of a viewer, creating its session when needed, only offers create sessions.
+// Empty state means no state change
+func (sc *Client) updateSession(clientID string, state SessionState, create bool) error {
+	// Only masters have viewers
+	if sc.config.Role != Master || clientID == "" {
+		return nil
+	}
+
+	sc.mu.Lock()
+	now := time.Now()
+	changed := false
+	var evicted []*session
+	s := sc.sessions[clientID]
+	if s == nil {
+		if !create {
+			sc.mu.Unlock()
+			return errNoSession
+		}
+		// Make room ending idle sessions, their viewers may be gone without a word
+		if sc.maxViewers > 0 && len(sc.sessions) >= sc.maxViewers {
+			evicted = sc.evictIdleSessionsLocked(now)
+		}
+		// No room for a new viewer
+		if sc.maxViewers > 0 && len(sc.sessions) >= sc.maxViewers {
+			sc.mu.Unlock()
+			sc.emitSessionsGone(evicted)
+			return fmt.Errorf("%w: %s", ErrTooManyViewers, clientID)
+		}
+		s = &session{Session: Session{ClientID: clientID, State: state, CreatedAt: now}}
+		sc.sessions[clientID] = s
+		changed = true
+	}
+
+	s.LastActivity = now
+	if state != "" && state != s.State {
+		s.State = state
+		changed = true
+	}
+
+	// Connected sessions never expire, others expire when they are idle for their state timeout
+	switch timeout := sc.sessionTimeoutLocked(s.State); {
+	case timeout <= 0:
+		if s.timer != nil {
+			s.timer.Stop()
+		}
+	case s.timer == nil:
+		s.timer = time.AfterFunc(timeout, func() {
+			sc.expireSession(s)
+		})
+	default:
+		s.timer.Reset(timeout)
+	}
+
+	snapshot := s.Session
+	sc.mu.Unlock()
+
+	sc.emitSessionsGone(evicted)
+	if changed {
+		sc.emitSessionChange(snapshot)
+	}
+	return nil
+}
+
+// Idle timer of a viewer session
+func (sc *Client) expireSession(s *session) {
+	sc.mu.Lock()
+	// Replaced, connected or active meanwhile
+	timeout := sc.sessionTimeoutLocked(s.State)
+	if sc.sessions[s.ClientID] != s || timeout <= 0 || time.Since(s.LastActivity) < timeout {
+		sc.mu.Unlock()
+		return
+	}
+	ended := sc.endSessionLocked(s.ClientID)
+	sc.mu.Unlock()
+
+	if ended != nil {
+		sc.emitSessionChange(ended.Session)
+	}
+}
+
+// Idle time before a session in input state expires, 0 if it never expires. Lock must be held by caller
+func (sc *Client) sessionTimeoutLocked(state SessionState) time.Duration {
+	switch state {
+	case SessionOfferReceived:
+		return sc.sessionIdleTimeout
+	case SessionAnswered:
+		return sc.answeredSessionIdleTimeout
+	default:
+		return 0
+	}
+}
+
+// End sessions not connected and idle for session idle timeout, even answered ones, lock must be
+// held by caller. It returns ended sessions
+func (sc *Client) evictIdleSessionsLocked(now time.Time) []*session {
+	if sc.sessionIdleTimeout <= 0 {
+		return nil
+	}
+
+	var evicted []*session
+	for clientID, s := range sc.sessions {
+		if s.State != SessionConnected && now.Sub(s.LastActivity) >= sc.sessionIdleTimeout {
+			evicted = append(evicted, sc.endSessionLocked(clientID))
+		}
+	}
+	return evicted
+}
+
+// Trigger Session Change Event of ended sessions
+func (sc *Client) emitSessionsGone(ended []*session) {
+	for _, s := range ended {
+		sc.emitSessionChange(s.Session)
+	}
+}
+
+// Remove viewer session and its per client state, lock must be held by caller. It returns removed session
+func (sc *Client) endSessionLocked(clientID string) *session {
+	s := sc.sessions[clientID]
+	if s == nil {
+		return nil
+	}
+
+	delete(sc.sessions, clientID)
+	delete(sc.hasReceivedRemoteSDPByClientID, clientID)
+	delete(sc.remoteUfragByClientID, clientID)
+	delete(sc.pendingIceCandidatesByClientID, clientID)
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+
+	s.State = SessionGone
+	return s
+}
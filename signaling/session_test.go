@@ -0,0 +1,341 @@
+package signaling_test
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
+	"github.com/stretchr/testify/assert"
+)
+
+// Sdp offer message from input viewer
+func sdpOfferFrom(clientID string) []byte {
+	return []byte(`{"messageType":"SDP_OFFER","messagePayload":"eyJzZHAiOiJvZmZlcj0gdHJ1ZVxudmlkZW89IHRydWUiLCJ0eXBlIjoib2ZmZXIifQ==","senderClientId":"` + clientID + `"}`)
+}
+
+// Testing viewer session goes through its states
+func TestSessionLifecycle(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket)
+
+	// if session change event
+	var mu sync.Mutex
+	states := []signaling.SessionState{}
+	client.OnSessionChange(func(session signaling.Session) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, clientID, session.ClientID)
+		states = append(states, session.State)
+	})
+
+	// Negotiation with viewer
+	ownMockWebsocket.onMessage(signaling.TextMessage, sdpOfferFrom(clientID))
+	client.SendSdpAnswer(SDPAnswer, &clientID)
+	assert.True(t, client.MarkConnected(clientID))
+
+	// Active session
+	sessions := client.Sessions()
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, clientID, sessions[0].ClientID)
+	assert.Equal(t, signaling.SessionConnected, sessions[0].State)
+
+	// Viewer is gone
+	client.EndSession(clientID)
+
+	// ASSERTS
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []signaling.SessionState{signaling.SessionOfferReceived, signaling.SessionAnswered,
+		signaling.SessionConnected, signaling.SessionGone}, states)
+	assert.Len(t, client.Sessions(), 0)
+	assert.False(t, client.MarkConnected(clientID))
+}
+
+// Testing offers from too many viewers are dropped
+func TestSessionMaxViewers(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client with a single viewer
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket, signaling.WithMaxViewers(1))
+
+	// Count offers
+	offers := []string{}
+	client.OnSdpOffer(func(offer *string, remoteClientID *string) {
+		offers = append(offers, *remoteClientID)
+	})
+
+	// if error event
+	var receivedErr error
+	client.OnError(func(err error) {
+		receivedErr = err
+	})
+
+	// Two viewers send their offers
+	ownMockWebsocket.onMessage(signaling.TextMessage, sdpOfferFrom("viewer1"))
+	ownMockWebsocket.onMessage(signaling.TextMessage, sdpOfferFrom("viewer2"))
+
+	// ASSERTS
+	assert.Equal(t, []string{"viewer1"}, offers)
+	assert.ErrorIs(t, receivedErr, signaling.ErrTooManyViewers)
+	assert.Len(t, client.Sessions(), 1)
+
+	// Room for second viewer when first one is gone
+	client.EndSession("viewer1")
+	ownMockWebsocket.onMessage(signaling.TextMessage, sdpOfferFrom("viewer2"))
+	assert.Equal(t, []string{"viewer1", "viewer2"}, offers)
+}
+
+// Testing viewers are unlimited by default, and limited on demand
+func TestSessionMaxViewersUnlimitedByDefault(t *testing.T) {
+	for _, test := range []struct {
+		name     string                    // Test name
+		options  []func(*signaling.Client) // Client options
+		sessions int                       // Sessions after offers of 11 viewers
+	}{
+		{name: "default", sessions: 11},
+		{name: "limited", options: []func(*signaling.Client){signaling.WithMaxViewers(10)}, sessions: 10},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			// Load Initial values
+			InitInfo()
+
+			// Open client
+			ownMockWebsocket := newMockWebSocket()
+			client := openMasterWithMock(t, ownMockWebsocket, test.options...)
+			client.OnSdpOffer(func(offer *string, remoteClientID *string) {})
+			client.OnError(func(err error) {})
+
+			// Eleven viewers send their offers
+			for i := 1; i <= 11; i++ {
+				ownMockWebsocket.onMessage(signaling.TextMessage, sdpOfferFrom("viewer"+strconv.Itoa(i)))
+			}
+
+			// ASSERTS
+			assert.Len(t, client.Sessions(), test.sessions)
+		})
+	}
+}
+
+// Testing idle sessions expire and free their per client state
+func TestSessionIdleExpiry(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create channel for control flow
+	c := make(chan string, 1)
+
+	// Open client with short idle timeout
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket, signaling.WithSessionIdleTimeout(20*time.Millisecond))
+
+	// if session change event
+	client.OnSessionChange(func(session signaling.Session) {
+		if session.State == signaling.SessionGone {
+			c <- "gone"
+		}
+	})
+
+	// Count delivered candidates
+	var mu sync.Mutex
+	candidates := 0
+	client.OnIceCandidate(func(iceCandidate *string, clientID *string) {
+		mu.Lock()
+		defer mu.Unlock()
+		candidates++
+	})
+	client.OnSdpOffer(func(offer *string, remoteClientID *string) {})
+
+	// Viewer never answers after its offer
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(sdpOfferViewerMessage))
+
+	// Wait until session expires
+	select {
+	case <-c:
+	case <-time.After(time.Second):
+		t.Fatalf("Session did not expire")
+	}
+
+	// Sdp of expired session is forgotten, candidate waits for a new offer
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(iceCandidateViewerMessage))
+
+	// ASSERTS
+	mu.Lock()
+	assert.Equal(t, 0, candidates)
+	mu.Unlock()
+	assert.Len(t, client.Sessions(), 0)
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(sdpOfferViewerMessage))
+	assert.Len(t, client.Sessions(), 1)
+	mu.Lock()
+	assert.Equal(t, 1, candidates)
+	mu.Unlock()
+}
+
+// Testing answered sessions outlive idle timeout of sessions not answered, their candidates are still delivered
+func TestSessionAnsweredDoesNotExpire(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client with short idle timeout
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket, signaling.WithSessionIdleTimeout(20*time.Millisecond))
+
+	// if session change event
+	var mu sync.Mutex
+	states := []signaling.SessionState{}
+	client.OnSessionChange(func(session signaling.Session) {
+		mu.Lock()
+		defer mu.Unlock()
+		states = append(states, session.State)
+	})
+
+	// Count delivered candidates
+	candidates := 0
+	client.OnIceCandidate(func(iceCandidate *string, clientID *string) {
+		mu.Lock()
+		defer mu.Unlock()
+		candidates++
+	})
+	client.OnSdpOffer(func(offer *string, remoteClientID *string) {})
+
+	// Viewer is answered, then it is quiet for longer than idle timeout
+	ownMockWebsocket.onMessage(signaling.TextMessage, sdpOfferFrom(clientID))
+	client.SendSdpAnswer(SDPAnswer, &clientID)
+	time.Sleep(100 * time.Millisecond)
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(iceCandidateViewerMessage))
+
+	// ASSERTS
+	sessions := client.Sessions()
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, signaling.SessionAnswered, sessions[0].State)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []signaling.SessionState{signaling.SessionOfferReceived, signaling.SessionAnswered}, states)
+	assert.Equal(t, 1, candidates)
+}
+
+// Testing a silent answered viewer does not keep its slot from a new viewer
+func TestSessionAnsweredIdleEvictedForNewViewer(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client with a single viewer and short idle timeout
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket, signaling.WithMaxViewers(1), signaling.WithSessionIdleTimeout(20*time.Millisecond))
+
+	// Error Event must not be triggered
+	client.OnError(func(err error) {
+		t.Errorf("Unexpected error: %v", err)
+	})
+
+	// if session change event
+	var mu sync.Mutex
+	gone := []string{}
+	client.OnSessionChange(func(session signaling.Session) {
+		mu.Lock()
+		defer mu.Unlock()
+		if session.State == signaling.SessionGone {
+			gone = append(gone, session.ClientID)
+		}
+	})
+	client.OnSdpOffer(func(offer *string, remoteClientID *string) {})
+
+	// First viewer is answered, then it goes silent
+	viewer1 := "viewer1"
+	ownMockWebsocket.onMessage(signaling.TextMessage, sdpOfferFrom(viewer1))
+	client.SendSdpAnswer(SDPAnswer, &viewer1)
+	time.Sleep(50 * time.Millisecond)
+
+	// New viewer connects
+	ownMockWebsocket.onMessage(signaling.TextMessage, sdpOfferFrom("viewer2"))
+
+	// ASSERTS
+	sessions := client.Sessions()
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, "viewer2", sessions[0].ClientID)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{viewer1}, gone)
+}
+
+// Testing answered sessions expire after their own idle timeout
+func TestSessionAnsweredIdleExpiry(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create channel for control flow
+	c := make(chan string, 1)
+
+	// Open client with short idle timeout for answered sessions
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket, signaling.WithAnsweredSessionIdleTimeout(20*time.Millisecond))
+
+	// if session change event
+	client.OnSessionChange(func(session signaling.Session) {
+		if session.State == signaling.SessionGone {
+			c <- session.ClientID
+		}
+	})
+	client.OnSdpOffer(func(offer *string, remoteClientID *string) {})
+
+	// Viewer is answered and never connects
+	ownMockWebsocket.onMessage(signaling.TextMessage, sdpOfferFrom(clientID))
+	client.SendSdpAnswer(SDPAnswer, &clientID)
+
+	// ASSERTS
+	select {
+	case gone := <-c:
+		assert.Equal(t, clientID, gone)
+	case <-time.After(time.Second):
+		t.Fatalf("Session did not expire")
+	}
+	assert.Len(t, client.Sessions(), 0)
+}
+
+// Testing ice candidates of unknown viewers do not take viewer slots
+func TestSessionCandidatesWithoutOffer(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client with a single viewer
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket, signaling.WithMaxViewers(1))
+
+	// Error Event must not be triggered
+	client.OnError(func(err error) {
+		t.Errorf("Unexpected error: %v", err)
+	})
+
+	// Count offers and candidates
+	offers := []string{}
+	client.OnSdpOffer(func(offer *string, remoteClientID *string) {
+		offers = append(offers, *remoteClientID)
+	})
+	candidates := 0
+	client.OnIceCandidate(func(iceCandidate *string, clientID *string) {
+		candidates++
+	})
+
+	// Candidates of other senders, then offer of viewer
+	for _, sender := range []string{"spoofed1", "spoofed2"} {
+		ownMockWebsocket.onMessage(signaling.TextMessage, []byte(strings.Replace(iceCandidateViewerMessage, "TestClientId", sender, 1)))
+	}
+	assert.Len(t, client.Sessions(), 0)
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(iceCandidateViewerMessage))
+	ownMockWebsocket.onMessage(signaling.TextMessage, sdpOfferFrom(clientID))
+
+	// ASSERTS
+	assert.Equal(t, []string{clientID}, offers)
+	assert.Equal(t, 1, candidates)
+	sessions := client.Sessions()
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, signaling.SessionOfferReceived, sessions[0].State)
+}
@@ -2,13 +2,17 @@ package signaling
 
 import (
 	"bytes"
+	"context"
 	b64 "encoding/base64"
 	"encoding/json"
-	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer"
 	signerV4 "github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer/v4"
+	awsV2 "github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 )
 
@@ -17,13 +21,35 @@ type WebSocketSignalingMessageSend struct {
 	MessageType       MessageType `json:"action,omitempty"`
 	MessagePayload    string      `json:"messagePayload,omitempty"`
 	RecipientClientID string      `json:"recipientClientId,omitempty"`
+	CorrelationID     string      `json:"correlationId,omitempty"`
 }
 
 // Signaling msg format for Reception
 type WebSocketSignalingMessageReceive struct {
-	MessageType    MessageType `json:"messageType,omitempty"`
-	MessagePayload string      `json:"messagePayload,omitempty"`
-	SenderClientID string      `json:"senderClientId,omitempty"`
+	MessageType        MessageType         `json:"messageType,omitempty"`
+	MessagePayload     string              `json:"messagePayload,omitempty"`
+	SenderClientID     string              `json:"senderClientId,omitempty"`
+	StatusResponse     *StatusResponse     `json:"statusResponse,omitempty"`
+	GoAway             *GoAway             `json:"-"`
+	ReconnectIceServer *ReconnectIceServer `json:"-"`
+}
+
+// Status response from signaling service, it reports a failure processing a sent message
+type StatusResponse struct {
+	CorrelationID string `json:"correlationId,omitempty"`
+	ErrorType     string `json:"errorType,omitempty"`
+	StatusCode    string `json:"statusCode,omitempty"`
+	Description   string `json:"description,omitempty"`
+}
+
+// Go away message from signaling service, current connection is going to be terminated
+type GoAway struct {
+	Payload string // Decoded message payload, usually empty
+}
+
+// Reconnect ICE server message from signaling service, ICE server configuration must be fetched again
+type ReconnectIceServer struct {
+	Payload string // Decoded message payload, usually empty
 }
 
 // Default service for v4 signature
@@ -33,6 +59,7 @@ var service = "kinesisvideo"
 type Config struct {
 	ChannelARN        *string            // ARN AWS Signaling Channel
 	ChannelEndpoint   *string            // WSS URL AWS signaling
+	HTTPSEndpoint     *string            // HTTPS URL AWS signaling, optional, used for ICE server configuration
 	Region            *string            // AWS Region
 	Role              Role               // Master or viewer actor
 	ClientID          *string            // Id Client for AWS signaling protocol
@@ -47,16 +74,20 @@ const (
 	sdpAnswer    MessageType = "SDP_ANSWER"
 	sdpOffer     MessageType = "SDP_OFFER"
 	iceCandidate MessageType = "ICE_CANDIDATE"
+
+	statusResponse     MessageType = "STATUS_RESPONSE"
+	goAway             MessageType = "GO_AWAY"
+	reconnectIceServer MessageType = "RECONNECT_ICE_SERVER"
 )
 
 // Signaling connection State Type
 type ReadyStateType string
 
 const (
-	connecting ReadyStateType = "CONNECTING"
-	open       ReadyStateType = "OPEN"
-	closing    ReadyStateType = "CLOSING"
-	closed     ReadyStateType = "CLOSED"
+	StateConnecting ReadyStateType = "CONNECTING"
+	StateOpen       ReadyStateType = "OPEN"
+	StateClosing    ReadyStateType = "CLOSING"
+	StateClosed     ReadyStateType = "CLOSED"
 )
 
 // Signaling client
@@ -64,7 +95,9 @@ type Client struct {
 	readyState                     ReadyStateType                               // Signaling cient connection status
 	config                         Config                                       // Signaling client configuration
 	signer                         signer.APII                                  // V4 AWS Signer
+	expireCredentials              func()                                       // Expire default signer credentials, nil for own signers
 	dateProvider                   *signer.DateProvier                          // Date provider for V4 AWS Signer
+	credentialsProvider            credentials.Provider                         // AWS Credentials provider for default signer, nil when not used
 	wsClient                       WebSocketClientI                             // Websocket client
 	onOpen                         func()                                       // Function for Open Event
 	onError                        func(err error)                              // Function for Error Event
@@ -72,22 +105,74 @@ type Client struct {
 	onSdpAnswer                    func(answer *string, clientID *string)       // Function for Sdp Answer Event
 	onSdpOffer                     func(offer *string, remoteClientID *string)  // Function for Sdp Offer Event
 	onIceCandidate                 func(iceCandidate *string, clientID *string) // Function for Ice Candidate Event
+	onGoAway                       func(goAway *GoAway)                         // Function for Go Away Event
+	onReconnectIceServer           func(msg *ReconnectIceServer)                // Function for Reconnect ICE Server Event
+	onStatusResponse               func(status *StatusResponse)                 // Function for Status Response Event
+	onStateChange                  func(old ReadyStateType, new ReadyStateType) // Function for State Change Event
+	onOffer                        func(desc SessionDescription, from string)   // Function for typed Offer Event
+	onAnswer                       func(desc SessionDescription, from string)   // Function for typed Answer Event
+	onCandidate                    func(init IceCandidateInit, from string)     // Function for typed Candidate Event
 	hasReceivedRemoteSDPByClientID map[string]bool                              // Maps for manage receive remote SDP by clientID
-	pendingIceCandidatesByClientID map[string][]string                          // Maps for manage pending Ice Candidate by clientID
+	pendingIceCandidatesByClientID map[string][]pendingIceCandidate             // Maps for manage pending Ice Candidate by clientID
+	remoteUfragByClientID          map[string]string                            // Maps for manage ICE username fragment of remote SDP by clientID
+	reconnectPolicy                *ReconnectPolicy                             // Reconnect policy, nil when disabled
+	reconnectAttempt               int                                          // Current reconnect attempt, 0 when not reconnecting
+	reconnectTimer                 *time.Timer                                  // Timer for next reconnect attempt, nil until Reconnecting Event returns
+	reconnectSeq                   uint64                                       // Reconnect attempts scheduled
+	reconnectPending               uint64                                       // Scheduled attempt waiting to start, 0 when none
+	credentialsRejected            bool                                         // Last connect attempt failed because of credentials
+	connectGen                     uint64                                       // Generation of current connect attempt, results of older ones are dropped
+	dialDone                       chan struct{}                                // Closed when dial of last connect attempt returns, nil before first one
+	openedAt                       time.Time                                    // When current websocket connection was opened
+	onReconnecting                 func(attempt int, delay time.Duration)       // Function for Reconnecting Event
+	onReconnected                  func(attempt int)                            // Function for Reconnected Event
+	wsClientFactory                func() WebSocketClientI                      // Websocket client factory, nil when using own websocket client
+	maxConnectionAge               time.Duration                                // Connection age limit, a fresh connection is opened ahead of it, 0 when disabled
+	refreshTimer                   *time.Timer                                  // Timer for next connection refresh
+	refreshAttempt                 int                                          // Failed refresh attempts of current connection
+	goingAway                      bool                                         // Connection closed after go away message
+	deliveries                     map[string]*Delivery                         // Pending deliveries by correlation id
+	statusResponseWindow           time.Duration                                // Wait for status response of tracked messages
+	handshakeTimeout               time.Duration                                // Websocket opening handshake timeout for default websocket client
+	events                         chan Event                                   // Event stream, nil until somebody asks for it
+	eventBuffer                    int                                          // Event stream buffer size
+	eventPolicy                    EventPolicy                                  // What to do when event stream buffer is full
+	closing                        chan struct{}                                // Closed by Close, so blocked event stream publishes give up
+	droppedEvents                  atomic.Uint64                                // Events dropped because event stream buffer was full
+	sessions                       map[string]*session                          // Viewer sessions by client id, only for master
+	maxViewers                     int                                          // Max concurrent viewer sessions, 0 means unlimited
+	sessionIdleTimeout             time.Duration                                // Idle time before a viewer session not answered expires
+	answeredSessionIdleTimeout     time.Duration                                // Idle time before an answered viewer session not connected expires
+	onSessionChange                func(session Session)                        // Function for Session Change Event
+	maxPendingIceCandidates        int                                          // Max pending Ice Candidates per clientID, 0 means unlimited
+	pendingIceCandidateTTL         time.Duration                                // Max time an Ice Candidate is pending, 0 means forever
+	droppedIceCandidates           atomic.Uint64                                // Pending Ice Candidates dropped
+	onIceCandidateDropped          func(candidate, from string, reason error)   // Function for Ice Candidate Dropped Event
+	onCredentialsRotated           func(accessKeyID string, expires time.Time)  // Function for Credentials Rotated Event
+	serverTime                     func(ctx context.Context) (time.Time, error) // AWS clock source for clock skew errors without date
+	onClockSkewCorrected           func(offset time.Duration)                   // Function for Clock Skew Corrected Event
+	inFlightSends                  *sync.WaitGroup                              // Sends over current connection, a replaced connection is closed after them
+	mu                             sync.Mutex                                   // Protect state, event functions, maps and timers
 }
 
 // On Open Event Function
 func (sc *Client) OnOpen(f func()) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
 	sc.onOpen = f
 }
 
 // On Close Event Function
 func (sc *Client) OnClose(f func()) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
 	sc.onClose = f
 }
 
 // OnError Event Function
 func (sc *Client) OnError(f func(err error)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
 	sc.onError = f
 }
 
@@ -118,20 +203,50 @@ func (sc *Client) onMessage(data []byte) {
 	switch messageParsed.MessageType {
 	// When receive a SDP Offer
 	case sdpOffer:
+		// Track viewer session, there may be no room for it
+		if err := sc.updateSession(messageParsed.SenderClientID, SessionOfferReceived, true); err != nil {
+			sc.emitError(err)
+			return
+		}
+		// A new offer starts over, previous sdp of same client and its candidates are not valid anymore
+		sc.resetRemoteSDP(&messageParsed.SenderClientID, sdpUfrag(messagePayloadParsed))
 		// Trigger on Sdp Offer Event
-		sc.onSdpOffer(&messagePayloadParsed, &messageParsed.SenderClientID)
+		sc.emitSdpOffer(&messagePayloadParsed, &messageParsed.SenderClientID)
 		sc.emitPendingIceCandidates(&messageParsed.SenderClientID)
 		return
 	// When receive a SDP Answer
 	case sdpAnswer:
 		// trigger on Sdp Answer Event
-		sc.onSdpAnswer(&messagePayloadParsed, &messageParsed.SenderClientID)
+		sc.emitSdpAnswer(&messagePayloadParsed, &messageParsed.SenderClientID)
 		sc.emitPendingIceCandidates(&messageParsed.SenderClientID)
 		return
 	// When receive a Ice Candidate
 	case iceCandidate:
+		// Register viewer activity, candidates of unknown senders wait for their offer without a session
+		_ = sc.updateSession(messageParsed.SenderClientID, "", false)
 		sc.emitOrQueueIceCandidate(&messagePayloadParsed, &messageParsed.SenderClientID)
 		return
+	// When service reports an error for a sent message
+	case statusResponse:
+		if messageParsed.StatusResponse == nil {
+			return
+		}
+		// Resolve delivery of sent message with same correlation id
+		sc.resolveDelivery(messageParsed.StatusResponse)
+		sc.emitStatusResponse(messageParsed.StatusResponse)
+		return
+	// When service is going to terminate connection
+	case goAway:
+		messageParsed.GoAway = &GoAway{Payload: messagePayloadParsed}
+		sc.emitGoAway(messageParsed.GoAway)
+		// Connect again before service terminates connection
+		sc.reconnectGracefully()
+		return
+	// When ICE servers must be fetched again
+	case reconnectIceServer:
+		messageParsed.ReconnectIceServer = &ReconnectIceServer{Payload: messagePayloadParsed}
+		sc.emitReconnectIceServer(messageParsed.ReconnectIceServer)
+		return
 	default:
 		// Unknown message
 		return
@@ -140,19 +255,53 @@ func (sc *Client) onMessage(data []byte) {
 
 // On Sdp Answer Event Function
 func (sc *Client) OnSdpAnswer(f func(answer *string, clientID *string)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
 	sc.onSdpAnswer = f
 }
 
 // On Sdp Offer Event Function
 func (sc *Client) OnSdpOffer(f func(offer *string, remoteClientID *string)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
 	sc.onSdpOffer = f
 }
 
 // On ICE Candidate Event Function
 func (sc *Client) OnIceCandidate(f func(iceCandidate *string, clientID *string)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
 	sc.onIceCandidate = f
 }
 
+// On Go Away Event Function, signaling client reconnects by itself after it
+func (sc *Client) OnGoAway(f func(goAway *GoAway)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.onGoAway = f
+}
+
+// On Reconnect ICE Server Event Function
+func (sc *Client) OnReconnectIceServer(f func(msg *ReconnectIceServer)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.onReconnectIceServer = f
+}
+
+// On Status Response Event Function
+func (sc *Client) OnStatusResponse(f func(status *StatusResponse)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.onStatusResponse = f
+}
+
+// On Credentials Rotated Event Function, triggered when default signer gets new credentials
+func (sc *Client) OnCredentialsRotated(f func(accessKeyID string, expires time.Time)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.onCredentialsRotated = f
+}
+
 // Optional parameters
 
 // Use own websocket client implementation
@@ -162,6 +311,21 @@ func WithWebsocketClient(websocket WebSocketClientI) func(*Client) {
 	}
 }
 
+// Use own websocket client factory, a new client is created for every connection refresh
+func WithWebsocketClientFactory(factory func() WebSocketClientI) func(*Client) {
+	return func(sc *Client) {
+		sc.wsClientFactory = factory
+	}
+}
+
+// Use own timeout for websocket opening handshake of default websocket client, it also bounds
+// the wait for Open Event of any websocket client once dialed
+func WithHandshakeTimeout(timeout time.Duration) func(*Client) {
+	return func(sc *Client) {
+		sc.handshakeTimeout = timeout
+	}
+}
+
 // Use own v4 AWS signer implementation
 func WithSigner(signer signer.APII) func(*Client) {
 	return func(sc *Client) {
@@ -169,6 +333,41 @@ func WithSigner(signer signer.APII) func(*Client) {
 	}
 }
 
+// Use own AWS credentials provider for default v4 signer, like an assume role or web identity one.
+// It takes precedence over config CredentialsValue
+func WithCredentialsProvider(provider credentials.Provider) func(*Client) {
+	return func(sc *Client) {
+		sc.credentialsProvider = provider
+	}
+}
+
+// Use own SDK agnostic credentials provider for default v4 signer.
+// It takes precedence over config CredentialsValue
+func WithProvider(provider signer.CredentialsProviderI) func(*Client) {
+	return func(sc *Client) {
+		sc.credentialsProvider = signerV4.NewProvider(provider)
+	}
+}
+
+// Use own aws-sdk-go-v2 credentials provider for default v4 signer.
+// It takes precedence over config CredentialsValue
+func WithAWSV2CredentialsProvider(provider awsV2.CredentialsProvider) func(*Client) {
+	return WithProvider(signerV4.NewAWSV2Provider(provider))
+}
+
+// Use credentials of an aws-sdk-go-v2 config for default v4 signer, and its region when config has no region
+func WithAWSV2Config(awsConfig awsV2.Config) func(*Client) {
+	return func(sc *Client) {
+		if sc.config.Region == nil && awsConfig.Region != "" {
+			region := awsConfig.Region
+			sc.config.Region = &region
+		}
+		if awsConfig.Credentials != nil {
+			WithAWSV2CredentialsProvider(awsConfig.Credentials)(sc)
+		}
+	}
+}
+
 // Use own Date Provider implementation
 func WithDateProvider(dateProvider signer.DateProvier) func(*Client) {
 	return func(sc *Client) {
@@ -176,21 +375,36 @@ func WithDateProvider(dateProvider signer.DateProvier) func(*Client) {
 	}
 }
 
-// New signaling client
+// New signaling client. Masters track a session per viewer that sends an offer, without limit of
+// viewers unless WithMaxViewers is used, and expire sessions not answered for DefaultSessionIdleTimeout
+// and answered ones not connected for DefaultAnsweredSessionIdleTimeout
 func New(config *Config, options ...func(*Client)) (*Client, error) {
 
 	// Config must never be nil
 	if config == nil {
-		return nil, errors.New("Config cannot be nil")
+		return nil, &ConfigError{Field: "Config", Reason: "cannot be nil"}
 	}
 
 	// New Signaling client with initial values
 	sc := &Client{
-		readyState:                     closed,
+		readyState:                     StateClosed,
 		config:                         *config,
 		hasReceivedRemoteSDPByClientID: make(map[string]bool),
-		pendingIceCandidatesByClientID: make(map[string][]string),
+		pendingIceCandidatesByClientID: make(map[string][]pendingIceCandidate),
+		remoteUfragByClientID:          make(map[string]string),
+		deliveries:                     make(map[string]*Delivery),
+		statusResponseWindow:           DefaultStatusResponseWindow,
+		eventBuffer:                    DefaultEventBuffer,
+		sessions:                       make(map[string]*session),
+		sessionIdleTimeout:             DefaultSessionIdleTimeout,
+		answeredSessionIdleTimeout:     DefaultAnsweredSessionIdleTimeout,
+		maxPendingIceCandidates:        DefaultMaxPendingIceCandidates,
+		pendingIceCandidateTTL:         DefaultPendingIceCandidateTTL,
+		closing:                        make(chan struct{}),
+		inFlightSends:                  &sync.WaitGroup{},
 	}
+	// CLOSED until first Open, event stream publishes do not block meanwhile
+	close(sc.closing)
 
 	// Getting other optional parameters
 	for _, o := range options {
@@ -203,7 +417,7 @@ func New(config *Config, options ...func(*Client)) (*Client, error) {
 	if config.Role == Viewer {
 		// Config clientID must never be nil
 		if config.ClientID == nil {
-			return nil, errors.New("clientID cannot be nil")
+			return nil, &ConfigError{Field: "clientID", Reason: "cannot be nil"}
 		}
 	}
 
@@ -211,37 +425,41 @@ func New(config *Config, options ...func(*Client)) (*Client, error) {
 	if config.Role == Master {
 		// Config clientID always nil
 		if config.ClientID != nil {
-			return nil, errors.New("clientID should be nil when master selected")
+			return nil, &ConfigError{Field: "clientID", Reason: "should be nil when master selected"}
 		}
 	}
 
 	// Config ChannelARN must never be nil
 	if config.ChannelARN == nil {
-		return nil, errors.New("channelARN cannot be nil")
+		return nil, &ConfigError{Field: "channelARN", Reason: "cannot be nil"}
 	}
 
-	// Config Region must never be nil
-	if config.Region == nil {
-		return nil, errors.New("region cannot be nil")
+	// Config Region must never be nil, it can come from options
+	if sc.config.Region == nil {
+		return nil, &ConfigError{Field: "region", Reason: "cannot be nil"}
 	}
 
 	// Config ChannelEndpoint must never be nil
 	if config.ChannelEndpoint == nil {
-		return nil, errors.New("channelEndpoint cannot be nil")
+		return nil, &ConfigError{Field: "channelEndpoint", Reason: "cannot be nil"}
 	}
 
 	// If you are not using our signer
 	if sc.signer == nil {
-		var kinesisVideoSigner signer.APII
+		var kinesisVideoSigner *signerV4.Signer
 		var err error
 		// Do you have own Credentials ?
-		if config.CredentialsValue != nil {
+		if sc.credentialsProvider != nil {
+			// Create new V4 signer with own Credentials provider
+			kinesisVideoSigner, err = signerV4.New(signerV4.WithRegion(*sc.config.Region),
+				signerV4.WithService(service), signerV4.WithCredentialsProvider(sc.credentialsProvider))
+		} else if config.CredentialsValue != nil {
 			// Create new V4 signer with own Credentials
-			kinesisVideoSigner, err = signerV4.New(signerV4.WithRegion(*config.Region),
+			kinesisVideoSigner, err = signerV4.New(signerV4.WithRegion(*sc.config.Region),
 				signerV4.WithService(service), signerV4.WithCredentialsValue(config.CredentialsValue))
 		} else {
 			// Create new V4 signer using AWS machine credentials provider
-			kinesisVideoSigner, err = signerV4.New(signerV4.WithRegion(*config.Region),
+			kinesisVideoSigner, err = signerV4.New(signerV4.WithRegion(*sc.config.Region),
 				signerV4.WithService(service))
 		}
 
@@ -250,20 +468,33 @@ func New(config *Config, options ...func(*Client)) (*Client, error) {
 			return nil, err
 		}
 
-		// Assing signer
+		// Assing signer, pending reconnect attempt uses new credentials at once
+		kinesisVideoSigner.OnCredentialsRotated(sc.handleCredentialsRotated)
 		sc.signer = kinesisVideoSigner
+		sc.expireCredentials = kinesisVideoSigner.ExpireCredentials
 	}
 
 	// If you are not using our date provider
 	if sc.dateProvider == nil {
 		// Assing date provider
 		sc.dateProvider = &signer.DateProvier{
-			ClockOffset: time.Duration(config.SystemClockOffset),
+			Offset: time.Duration(config.SystemClockOffset) * time.Millisecond,
 		}
 	}
 	// If you are not using our websocket client
 	if sc.wsClient == nil {
-		sc.wsClient = &WebSocketClient{}
+		// Use default factory if there is no own factory
+		if sc.wsClientFactory == nil {
+			sc.wsClientFactory = func() WebSocketClientI {
+				return &WebSocketClient{HandshakeTimeout: sc.handshakeTimeout}
+			}
+		}
+		sc.wsClient = sc.wsClientFactory()
+	}
+
+	// Connection refresh dials a new websocket client in parallel, own websocket client alone can not do it
+	if sc.maxConnectionAge > 0 && sc.wsClientFactory == nil {
+		return nil, &ConfigError{Field: "maxConnectionAge", Reason: "needs a websocket client factory when own websocket client is used"}
 	}
 
 	// return signaling client
@@ -272,110 +503,457 @@ func New(config *Config, options ...func(*Client)) (*Client, error) {
 
 // Open Signaling Client
 func (sc *Client) Open() error {
-	// Check if reOpen action
-	if sc.readyState != closed {
-		err := errors.New("client is already open, opening, or closing")
-		if sc.onError != nil {
-			// Trigger Error Event
-			sc.onError(err)
-		}
+	// Check if reOpen action, change signaling client state otherwise
+	gen, ok := sc.startConnecting()
+	if !ok {
+		// Trigger Error Event
+		sc.emitError(ErrAlreadyOpen)
+		return ErrAlreadyOpen
+	}
+
+	// Go Rutine for connect to websocket signaling channel
+	go sc.connect(context.Background(), gen)
+
+	return nil
+}
+
+// OpenContext opens signaling client and waits until connection is OPEN, it returns signing and dial
+// errors. Signaling client is closed when context is done before connection is OPEN
+func (sc *Client) OpenContext(ctx context.Context) error {
+	// Caller gave up
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
-	// Change signaling client state
-	sc.readyState = connecting
+	// Check if reOpen action, change signaling client state otherwise
+	gen, ok := sc.startConnecting()
+	if !ok {
+		// Trigger Error Event
+		sc.emitError(ErrAlreadyOpen)
+		return ErrAlreadyOpen
+	}
 
-	// Go Rutine for connect to websocket signaling channel
+	// Connect in background, so context is honoured even if websocket client does not
+	result := make(chan error, 1)
 	go func() {
+		result <- sc.connect(ctx, gen)
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		sc.abortOpen()
+		return ctx.Err()
+	}
+}
+
+// Go from CLOSED to CONNECTING for a new connect attempt, it returns attempt generation
+func (sc *Client) startConnecting() (uint64, bool) {
+	sc.mu.Lock()
+	old, ok := sc.transitionLocked(StateConnecting, StateClosed)
+	if !ok {
+		sc.mu.Unlock()
+		return 0, false
+	}
+	sc.connectGen++
+	gen := sc.connectGen
+	sc.mu.Unlock()
+
+	sc.emitStateChange(old, StateConnecting)
+	return gen, true
+}
+
+// Check if connect attempt generation is current one
+func (sc *Client) isCurrentGen(gen uint64) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.connectGen == gen
+}
+
+// Caller gave up opening, signaling client is CLOSED at once even if websocket client is still dialing.
+// Results of abandoned attempt are dropped
+func (sc *Client) abortOpen() {
+	sc.mu.Lock()
+	// Event stream consumer may be gone with the caller
+	sc.unblockEventsLocked()
+	old, ok := sc.transitionLocked(StateClosed, StateConnecting)
+	if ok {
+		sc.connectGen++
+	}
+	sc.mu.Unlock()
+
+	// Opened meanwhile
+	if !ok {
+		sc.Close()
+		return
+	}
+
+	// Websocket clients without context support may stop dialing when closed
+	sc.currentWebsocket().Close()
+
+	sc.emitStateChange(old, StateClosed)
+	sc.emitClose()
+}
+
+// Sign channel endpoint and dial websocket, used by Open and every reconnect attempt.
+// It returns when connection is OPEN or connection attempt failed. Attempt generation tells apart
+// results of attempts abandoned meanwhile
+func (sc *Client) connect(ctx context.Context, gen uint64) error {
+	return sc.connectAttempt(ctx, gen, true)
+}
+
+// Sign and dial, a handshake rejected because of clock skew or credentials is tried again once
+// with corrected clock or refreshed credentials
+func (sc *Client) connectAttempt(ctx context.Context, gen uint64, retry bool) error {
+
+	// AWS V4 Sing channel endpoint uri, signed again on every attempt
+	signedURL, err := sc.signURL()
+
+	// if something wrong happened
+	if err != nil {
+		sc.connectFailed(gen, err)
+		return err
+	}
+
+	// Websocket client is never dialed twice at once, dial of an abandoned attempt may be running
+	if err := sc.waitDialDone(ctx); err != nil {
+		return err
+	}
 
-		// Prepare data for sign request
-		queryParams := signer.QueryParams{
-			"X-Amz-channelARN": *sc.config.ChannelARN,
+	sc.mu.Lock()
+	// Abandoned meanwhile, nothing to do
+	if sc.connectGen != gen {
+		sc.mu.Unlock()
+		return ErrClosed
+	}
+	// If other process changed signaling client status nothing to do
+	if sc.readyState != StateConnecting {
+		sc.mu.Unlock()
+		// Closed while signing
+		if sc.transition(StateClosed, StateClosing) {
+			sc.emitClose()
 		}
-		// If viewer actor
-		if sc.config.Role == Viewer {
-			queryParams["X-Amz-ClientID"] = *sc.config.ClientID
+		return ErrClosed
+	}
+	dialDone := make(chan struct{})
+	sc.dialDone = dialDone
+	// Dial current websocket client
+	ws := sc.wsClient
+	sc.mu.Unlock()
+
+	// An Open Event arriving after attempt was given up is dropped
+	opened := make(chan bool, 1)
+	var settled atomic.Bool
+	err = sc.dial(ctx, gen, ws, signedURL, func() {
+		if !settled.CompareAndSwap(false, true) {
+			sc.dropLateOpen(gen, ws)
+			return
 		}
+		opened <- sc.handleOpen(gen, ws)
+	})
+	close(dialDone)
 
-		// AWS V4 Sing channel endpoint uri
-		signedURL, err := sc.signer.GetSignedURL(*sc.config.ChannelEndpoint, queryParams, sc.dateProvider.GetDate())
+	// Signed again with corrected clock or refreshed credentials
+	if err != nil && retry && (sc.correctClockSkew(ctx, err) || sc.refreshCredentials(err)) {
+		return sc.connectAttempt(ctx, gen, false)
+	}
 
-		// if something wrong happened
-		if err != nil {
-			// Trigger Error Event
-			sc.onError(err)
+	// if something wrong happened
+	if err != nil {
+		sc.connectFailed(gen, err)
+		return err
+	}
+
+	// Websocket clients may trigger Open Event after dial returns, but not forever
+	timer := time.NewTimer(sc.openTimeout())
+	defer timer.Stop()
+	select {
+	case ok := <-opened:
+		if !ok {
+			return ErrClosed
 		}
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timer.C:
+		err = ErrOpenTimeout
+	}
 
-		// If other process changed signaling client status nothing to do
-		if sc.readyState != connecting {
-			return
+	// Open Event arrived meanwhile, its result is on the way
+	if !settled.CompareAndSwap(false, true) {
+		if !<-opened {
+			return ErrClosed
 		}
+		return nil
+	}
 
-		// Set signed url to websocket client
-		err = sc.wsClient.SetURL(signedURL)
-		if err != nil {
-			sc.onError(err)
+	// Caller gave up, OpenContext closes signaling client
+	if err != ErrOpenTimeout {
+		return err
+	}
+	sc.connectFailed(gen, err)
+	return err
+}
+
+// Wait until dial of last connect attempt returns
+func (sc *Client) waitDialDone(ctx context.Context) error {
+	sc.mu.Lock()
+	dialDone := sc.dialDone
+	sc.mu.Unlock()
+
+	// Never dialed
+	if dialDone == nil {
+		return nil
+	}
+	select {
+	case <-dialDone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Time to wait for Open Event once websocket client was dialed
+func (sc *Client) openTimeout() time.Duration {
+	if sc.handshakeTimeout > 0 {
+		return sc.handshakeTimeout
+	}
+	return DefaultHandshakeTimeout
+}
+
+// Open Event of a connection attempt given up, the connection is not used unless websocket client
+// is dialed again for next attempt. Connections of abandoned attempts are never used
+func (sc *Client) dropLateOpen(gen uint64, ws WebSocketClientI) {
+	sc.mu.Lock()
+	current := sc.wsClient == ws && sc.connectGen == gen
+	state := sc.readyState
+	sc.mu.Unlock()
+
+	if !current {
+		ws.Close()
+		return
+	}
+	switch state {
+	case StateClosed, StateClosing:
+		ws.Close()
+	}
+}
+
+// Sign channel endpoint uri for a new websocket connection
+func (sc *Client) signURL() (string, error) {
+	// Prepare data for sign request
+	queryParams := signer.QueryParams{
+		"X-Amz-channelARN": *sc.config.ChannelARN,
+	}
+	// If viewer actor
+	if sc.config.Role == Viewer {
+		queryParams["X-Amz-ClientID"] = *sc.config.ClientID
+	}
+
+	// Clock offset changes after clock skew errors
+	sc.mu.Lock()
+	date := sc.dateProvider.GetDate()
+	sc.mu.Unlock()
+
+	return sc.signer.GetSignedURL(*sc.config.ChannelEndpoint, queryParams, date)
+}
+
+// Bind websocket events to signaling client and dial it, events are dropped once connect attempt
+// generation is not current
+func (sc *Client) dial(ctx context.Context, gen uint64, ws WebSocketClientI, signedURL string, onOpen func()) error {
+	// Set signed url to websocket client
+	err := ws.SetURL(signedURL)
+	if err != nil {
+		return err
+	}
+
+	// When websocket Open event do
+	ws.OnOpen(onOpen)
+
+	// When websocket Error event do
+	ws.OnError(func(err error) {
+		if sc.isCurrentGen(gen) {
+			sc.emitError(err)
 		}
+	})
 
-		// When websocket Open event do
-		sc.wsClient.OnOpen(func() {
-			sc.readyState = open
-			if sc.onOpen != nil {
-				sc.onOpen()
-			}
-		})
+	// When websocket Close event do, ignore connections already replaced or abandoned
+	ws.OnClose(func() {
+		if sc.currentWebsocket() == ws && sc.isCurrentGen(gen) {
+			sc.handleClose()
+		}
+	})
 
-		// When websocket Error event do
-		sc.wsClient.OnError(func(err error) {
-			sc.onError(err)
-		})
+	// Wait channel define for OnMessage lock until dial Ok
+	dial := make(chan string, 1)
 
-		// When websocket Close event do
-		sc.wsClient.OnClose(func() {
-			sc.readyState = closed
-			sc.wsClient = nil
-			sc.onClose()
-		})
+	// When websocket Message event do
+	ws.OnMessage(dial, func(messageType int, data []byte) {
+		sc.onMessage(data)
+	})
 
-		// Wait channel define for OnMessage lock until dial Ok
-		dial := make(chan string)
+	// Dial websocket client, using context when websocket client supports it
+	if contextDialer, ok := ws.(WebSocketContextDialer); ok {
+		err = contextDialer.DialContext(ctx)
+	} else {
+		err = ws.Dial()
+	}
 
-		// When websocket Message event do
-		sc.wsClient.OnMessage(dial, func(messageType int, data []byte) {
-			sc.onMessage(data)
-		})
+	// Dial done, unlock channel
+	dial <- "done"
 
-		// Dial websocket client
-		err = sc.wsClient.Dial()
+	return err
+}
 
-		// if something wrong happened
-		if err != nil {
-			// Trigger Error Event
-			sc.onError(err)
+// Get websocket client in use
+func (sc *Client) currentWebsocket() WebSocketClientI {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.wsClient
+}
+
+// Connection attempt failed, retry when reconnecting or go back to CLOSED. Failures of
+// abandoned attempts are dropped
+func (sc *Client) connectFailed(gen uint64, err error) {
+	if !sc.isCurrentGen(gen) {
+		return
+	}
+
+	if err != nil {
+		// Trigger Error Event
+		sc.emitError(err)
+	}
+
+	sc.mu.Lock()
+	reconnecting := sc.reconnectAttempt > 0 && sc.readyState == StateConnecting
+	sc.credentialsRejected = isCredentialsRejection(err)
+	sc.mu.Unlock()
+
+	// Failed reconnect attempt, try again unless signaling service rejected it for good
+	if reconnecting && !sc.isRejected(err) {
+		sc.scheduleReconnect(false)
+		return
+	}
+
+	// Closed while connecting
+	if sc.transition(StateClosed, StateClosing) {
+		sc.emitClose()
+		return
+	}
+
+	// Failed Open, or rejected reconnect attempt so signaling client goes offline
+	sc.mu.Lock()
+	sc.reconnectAttempt = 0
+	sc.mu.Unlock()
+	if sc.transition(StateClosed, StateConnecting) && reconnecting {
+		sc.emitClose()
+	}
+}
+
+// Websocket Open event, return false if signaling client was closed while dialing or attempt was abandoned
+func (sc *Client) handleOpen(gen uint64, ws WebSocketClientI) bool {
+	sc.mu.Lock()
+	// Abandoned meanwhile, its connection is not used
+	if sc.connectGen != gen {
+		sc.mu.Unlock()
+		ws.Close()
+		return false
+	}
+	old, ok := sc.transitionLocked(StateOpen, StateConnecting)
+	if !ok {
+		state := sc.readyState
+		sc.mu.Unlock()
+		// Closed while dialing
+		switch state {
+		case StateClosed, StateClosing:
+			ws.Close()
 		}
+		return false
+	}
+	sc.openedAt = time.Now()
+	sc.credentialsRejected = false
+	attempt := sc.reconnectAttempt
+	sc.mu.Unlock()
+	sc.emitStateChange(old, StateOpen)
+
+	// Refresh connection before service terminates it
+	sc.scheduleRefresh()
+
+	// Open after a reconnect attempt
+	if attempt > 0 {
+		sc.emitReconnected(attempt)
+		return true
+	}
 
-		// Dial Ok, unlock channel
-		dial <- "done"
+	sc.emitOpen()
+	return true
+}
 
-	}()
+// Websocket Close event
+func (sc *Client) handleClose() {
+	sc.stopRefresh()
+
+	// Status responses for sent messages will never arrive
+	sc.failDeliveries(ErrDeliveryUnconfirmed)
+
+	sc.mu.Lock()
+	// Closed after go away message
+	goingAway := sc.goingAway
+	sc.goingAway = false
+	// Closed on purpose or reconnect disabled
+	reconnect := sc.readyState != StateClosing && (sc.reconnectPolicy != nil || goingAway)
+	// Unexpected close, reset attempts if last connection was stable
+	if reconnect && time.Since(sc.openedAt) >= sc.activeReconnectPolicy().ResetAfter {
+		sc.reconnectAttempt = 0
+	}
+	sc.mu.Unlock()
 
-	return nil
+	if !reconnect {
+		if sc.transition(StateClosed, StateClosing, StateOpen, StateConnecting) {
+			sc.emitClose()
+		}
+		return
+	}
+
+	// Service asked for it, connect again without waiting
+	sc.scheduleReconnect(goingAway)
 }
 
 // Close signaling client
 func (sc *Client) Close() {
-	// If websocket exists
-	if sc.wsClient != nil {
-		// Change signaling client status
-		sc.readyState = closing
-		// Close websocket client
-		sc.wsClient.Close()
+	// Nothing to do when signaling client is already CLOSED
+	if sc.State() == StateClosed {
+		return
+	}
+
+	sc.stopRefresh()
+
+	sc.mu.Lock()
+	// Close may be called by event stream consumer, so publishes must not wait for it anymore
+	sc.unblockEventsLocked()
+
+	// Waiting for next reconnect attempt, no websocket to close
+	if sc.stopReconnectLocked() {
+		old, ok := sc.transitionLocked(StateClosed, StateConnecting)
+		sc.mu.Unlock()
+		if ok {
+			sc.emitStateChange(old, StateClosed)
+			sc.emitClose()
+		}
+		return
+	}
 
-	} else if sc.readyState != closed {
-		// trigger Close event
-		sc.onClose()
+	// Change signaling client status, nothing to do if it is already CLOSING
+	old, ok := sc.transitionLocked(StateClosing, StateOpen, StateConnecting)
+	sc.mu.Unlock()
+	if !ok {
+		return
 	}
-	// Nothing to do when websocket client does not exist and signaling client status is different than CLOSED
+	sc.emitStateChange(old, StateClosing)
+
+	// Close websocket client
+	sc.currentWebsocket().Close()
 }
 
 // Use for emit Ice Candidate Messages when signaling client has recive SDP message
@@ -389,19 +967,23 @@ func (sc *Client) emitOrQueueIceCandidate(iceCandidate *string, clientID *string
 		clientIDKEY = *clientID
 	}
 
-	// If signaling client has recive SDP message
-	if sc.hasReceivedRemoteSDPByClientID[clientIDKEY] {
+	ufrag := candidateUfrag(*iceCandidate)
+
+	sc.mu.Lock()
+	// If signaling client has recive SDP message of candidate negotiation, a restarting client
+	// may send candidates of its new negotiation before its new offer
+	if sc.hasReceivedRemoteSDPByClientID[clientIDKEY] && sc.isCurrentNegotiationLocked(clientIDKEY, ufrag) {
+		sc.mu.Unlock()
 		// trigger Ice Candidate Event
-		sc.onIceCandidate(iceCandidate, clientID)
-	} else {
-		// If it is the first Ice Candidate message for this client Id
-		if sc.pendingIceCandidatesByClientID == nil || sc.pendingIceCandidatesByClientID[clientIDKEY] == nil {
-			sc.pendingIceCandidatesByClientID[clientIDKEY] = []string{}
-		}
-		// Queue Ice Candidate Message for this client Id
-		sc.pendingIceCandidatesByClientID[clientIDKEY] = append(sc.pendingIceCandidatesByClientID[clientIDKEY], *iceCandidate)
+		sc.emitIceCandidate(iceCandidate, clientID)
+		return
 	}
 
+	// Queue Ice Candidate Message for this client Id, it may drop candidates
+	dropped := sc.queueIceCandidateLocked(clientIDKEY, *iceCandidate, ufrag, time.Now())
+	sc.mu.Unlock()
+
+	sc.dropIceCandidates(dropped)
 }
 
 // Use for emit Ice Candidate Messages
@@ -415,100 +997,156 @@ func (sc *Client) emitPendingIceCandidates(clientID *string) {
 		clientIDKEY = *clientID
 	}
 
+	sc.mu.Lock()
 	// Set Sdp message receive for this client id
 	sc.hasReceivedRemoteSDPByClientID[clientIDKEY] = true
 
-	// Get Ice Candidate messages queue
+	// Get Ice Candidate messages queue without expired ones and clean it
+	dropped := sc.evictExpiredIceCandidatesLocked(time.Now())
 	pendingIceCandidates := sc.pendingIceCandidatesByClientID[clientIDKEY]
+	delete(sc.pendingIceCandidatesByClientID, clientIDKEY)
+	sc.mu.Unlock()
 
-	// If empty nothing to do
-	if pendingIceCandidates == nil {
-		return
-	}
-
-	// Clean Ice Candidate queue
-	sc.pendingIceCandidatesByClientID[clientIDKEY] = nil
+	sc.dropIceCandidates(dropped)
 
 	// trigger Ice Candidate events, one by one
 	for i := range pendingIceCandidates {
-		sc.onIceCandidate(&pendingIceCandidates[i], clientID)
+		sc.emitIceCandidate(&pendingIceCandidates[i].iceCandidate, clientID)
 	}
 
 }
 
-// Sender signalingSdp Offer Messages
-func (sc *Client) SendSdpOffer(sdpOfferMsg string, recipientClientID *string) {
-	var clientID string
+// Forget remote SDP of client Id and start a negotiation with input ICE username fragment. Queued
+// Ice Candidate Messages of other negotiations, like the previous one, are dropped
+func (sc *Client) resetRemoteSDP(clientID *string, ufrag string) {
+	clientIDKEY := string(DefaultClientID)
+	if clientID != nil {
+		clientIDKEY = *clientID
+	}
 
-	// Assing client Id if exists
-	if recipientClientID != nil {
-		clientID = *recipientClientID
+	sc.mu.Lock()
+	delete(sc.hasReceivedRemoteSDPByClientID, clientIDKEY)
+	if ufrag == "" {
+		delete(sc.remoteUfragByClientID, clientIDKEY)
+	} else {
+		sc.remoteUfragByClientID[clientIDKEY] = ufrag
+	}
+
+	// Keep candidates of new negotiation only
+	var dropped []droppedIceCandidate
+	var pendingIceCandidates []pendingIceCandidate
+	for _, p := range sc.pendingIceCandidatesByClientID[clientIDKEY] {
+		if sc.isCurrentNegotiationLocked(clientIDKEY, p.ufrag) {
+			pendingIceCandidates = append(pendingIceCandidates, p)
+		} else {
+			dropped = append(dropped, droppedIceCandidate{iceCandidate: p.iceCandidate, clientID: clientIDKEY, reason: ErrIceCandidateStale})
+		}
 	}
-	// Send Message
-	sc.sendMessage(sdpOffer, sdpOfferMsg, clientID)
+	if len(pendingIceCandidates) == 0 {
+		delete(sc.pendingIceCandidatesByClientID, clientIDKEY)
+	} else {
+		sc.pendingIceCandidatesByClientID[clientIDKEY] = pendingIceCandidates
+	}
+	sc.mu.Unlock()
+
+	sc.dropIceCandidates(dropped)
+}
+
+// Sender signalingSdp Offer Messages
+func (sc *Client) SendSdpOffer(sdpOfferMsg string, recipientClientID *string) {
+	// Send Message, errors are reported by Error Event
+	_ = sc.SendSdpOfferContext(context.Background(), sdpOfferMsg, recipientClientID)
 }
 
 // Sender signaling Ice Candidate Messages
 func (sc *Client) SendIceCandidate(iceCandidateMsg string, recipientClientID *string) {
-	var clientID string
-
-	// Assing client Id if exists
-	if recipientClientID != nil {
-		clientID = *recipientClientID
-	}
-	// Send Message
-	sc.sendMessage(iceCandidate, iceCandidateMsg, clientID)
+	// Send Message, errors are reported by Error Event
+	_ = sc.SendIceCandidateContext(context.Background(), iceCandidateMsg, recipientClientID)
 }
 
 // Sender signaling Sdp Answer Messages
 func (sc *Client) SendSdpAnswer(sdpAnswerMsg string, recipientClientID *string) {
-	var clientID string
+	// Send Message, errors are reported by Error Event
+	_ = sc.SendSdpAnswerContext(context.Background(), sdpAnswerMsg, recipientClientID)
+}
+
+// Generic Sender signaling Messages
+func (sc *Client) sendMessage(ctx context.Context, msgType MessageType, payload string, recipientClientID string, correlationID string) error {
+	err := sc.trySendMessage(ctx, msgType, payload, recipientClientID, correlationID)
+	if err != nil {
+		// Trigger Error Event, as always
+		sc.emitError(err)
+		return err
+	}
 
-	// Assing client Id if exists
-	if recipientClientID != nil {
-		clientID = *recipientClientID
+	// Activity of viewer session, if it exists
+	state := SessionState("")
+	if msgType == sdpAnswer {
+		state = SessionAnswered
 	}
-	// Send Message
-	sc.sendMessage(sdpAnswer, sdpAnswerMsg, clientID)
+	_ = sc.updateSession(recipientClientID, state, false)
+	return nil
 }
 
-// Generic Sender signaling Messages
-func (sc *Client) sendMessage(msgType MessageType, payload string, recipientClientID string) {
+// Generic Sender signaling Messages without Error Event
+func (sc *Client) trySendMessage(ctx context.Context, msgType MessageType, payload string, recipientClientID string, correlationID string) error {
+	// Caller gave up
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	// If signaling client status is different to OPEN, you can't send message
-	if sc.readyState != open {
-		err := errors.New("could not send message because the connection to the signaling service is not open")
-		sc.onError(err)
-		return
+	if sc.State() != StateOpen {
+		return ErrNotOpen
 	}
 
 	// Check if Recipient Client is valid
-	if sc.validateRecipientClientID(&recipientClientID) {
-		// Create WebSocket Signaling Message
-		wsMessage := WebSocketSignalingMessageSend{
-			MessageType:       msgType,
-			MessagePayload:    b64.StdEncoding.EncodeToString([]byte(payload)),
-			RecipientClientID: recipientClientID,
-		}
-		// Encode Message
-		wsMessageBytes, _ := json.Marshal(wsMessage)
+	if err := sc.validateRecipientClientID(&recipientClientID); err != nil {
+		return err
+	}
 
-		// Send Message over websocket
-		err := sc.wsClient.Send(TextMessage, wsMessageBytes)
-		if err != nil {
-			sc.onError(err)
-			return
-		}
+	// Create WebSocket Signaling Message
+	wsMessage := WebSocketSignalingMessageSend{
+		MessageType:       msgType,
+		MessagePayload:    b64.StdEncoding.EncodeToString([]byte(payload)),
+		RecipientClientID: recipientClientID,
+		CorrelationID:     correlationID,
+	}
+	// Encode Message
+	wsMessageBytes, _ := json.Marshal(wsMessage)
+
+	// Send Message over websocket, a replaced connection is not closed until send is over. Sends are
+	// never held by a pending replace, so events published meanwhile do not wait for it
+	sc.mu.Lock()
+	ws := sc.wsClient
+	inFlightSends := sc.inFlightSends
+	inFlightSends.Add(1)
+	sc.mu.Unlock()
+	defer inFlightSends.Done()
+
+	// Use context when websocket client supports it
+	var err error
+	if contextSender, ok := ws.(WebSocketContextSender); ok {
+		err = contextSender.SendContext(ctx, TextMessage, wsMessageBytes)
+	} else {
+		err = ws.Send(TextMessage, wsMessageBytes)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSendFailed, err)
+	}
+
+	// Tracked message is confirmed by this connection only
+	if correlationID != "" {
+		sc.deliverySent(correlationID, ws)
 	}
 
+	return nil
 }
 
 // Error if Recipient Client Id exists and actor is viewer
-func (sc *Client) validateRecipientClientID(recipientClientID *string) bool {
+func (sc *Client) validateRecipientClientID(recipientClientID *string) error {
 	if sc.config.Role == Viewer && recipientClientID != nil && *recipientClientID != "" {
-		err := errors.New("unexpected recipient client id. As the VIEWER, messages must not be sent with a recipient client id")
-		sc.onError(err)
-		return false
+		return ErrUnexpectedRecipient
 	}
-	return true
+	return nil
 }
@@ -1,12 +1,17 @@
 package signaling_test
 
 import (
+	"context"
 	"errors"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
 	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer"
+	awsV2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -38,6 +43,10 @@ var (
 	iceCandidateViewerMessage = `{"messageType":"ICE_CANDIDATE","messagePayload":"eyJjYW5kaWRhdGUiOiJ1cGQgMTAuMTExLjM0Ljg4Iiwic2RwTWlkIjoiMSIsInNkcE1MaW5lSW5kZXgiOjF9","senderClientId":"TestClientId"}`
 	iceCandidateMasterMessage = `{"messageType":"ICE_CANDIDATE","messagePayload":"eyJjYW5kaWRhdGUiOiJ1cGQgMTAuMTExLjM0Ljg4Iiwic2RwTWlkIjoiMSIsInNkcE1MaW5lSW5kZXgiOjF9"}`
 	iceCandidateMaster        = `{"action":"ICE_CANDIDATE","messagePayload":"eyJjYW5kaWRhdGUiOiJ1cGQgMTAuMTExLjM0Ljg4Iiwic2RwTWlkIjoiMSIsInNkcE1MaW5lSW5kZXgiOjF9","recipientClientId":"TestClientId"}`
+
+	statusResponseMessage     = `{"messageType":"STATUS_RESPONSE","statusResponse":{"correlationId":"1234","errorType":"InvalidArgumentException","statusCode":"400","description":"Recipient client id not connected"}}`
+	goAwayMessage             = `{"messageType":"GO_AWAY"}`
+	reconnectIceServerMessage = `{"messageType":"RECONNECT_ICE_SERVER"}`
 )
 
 // Testing signaling configures
@@ -407,7 +416,10 @@ func TestOpenConnectionClockOffset(t *testing.T) {
 		ownMockSigner.AssertCalled(t, "GetSignedURL", ENDPOINT, signer.QueryParams{
 			"X-Amz-channelARN": channelARN,
 			"X-Amz-ClientID":   clientID},
-			mock.Anything) // TODO: Test GeDate()
+			mock.MatchedBy(func(date *time.Time) bool {
+				// SystemClockOffset is in milliseconds
+				return date.Sub(time.Now().Add(1000*time.Second)).Abs() < time.Second
+			}))
 		c <- "done"
 	})
 
@@ -1799,3 +1811,258 @@ func TestEventIceCandidateFromViewer(t *testing.T) {
 	}
 
 }
+
+// Testing Events Status Response
+func TestEventStatusResponse(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create channel for control flow
+	c := make(chan string)
+
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+	// Create mock WebSocket
+	ownMockWebsocket := &mockWebSocket{}
+	// Expected mock WebSocket functions
+	ownMockWebsocket.On("Dial").Return(nil)
+	ownMockWebsocket.On("SetURL", mock.Anything).Return(nil)
+	ownMockWebsocket.On("Close").Return()
+	ownMockWebsocket.On("OnMessage", mock.Anything, mock.Anything).Return()
+
+	// New Signaling with mock
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner), signaling.WithWebsocketClient(ownMockWebsocket))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// if status response event
+	client.OnStatusResponse(func(status *signaling.StatusResponse) {
+		assert.Equal(t, "1234", status.CorrelationID)
+		assert.Equal(t, "InvalidArgumentException", status.ErrorType)
+		assert.Equal(t, "400", status.StatusCode)
+		assert.Equal(t, "Recipient client id not connected", status.Description)
+		c <- "done"
+	})
+
+	// if open event
+	client.OnOpen(func() {
+		ownMockWebsocket.onMessage(signaling.TextMessage, []byte(statusResponseMessage))
+	})
+
+	// Signaling Open Connection
+	err = client.Open()
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	// Wait until done
+	if <-c != "done" {
+		t.Errorf("Unexpected error")
+	}
+}
+
+// Testing Events Reconnect ICE Server
+func TestEventReconnectIceServer(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create channel for control flow
+	c := make(chan string)
+
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+	// Create mock WebSocket
+	ownMockWebsocket := &mockWebSocket{}
+	// Expected mock WebSocket functions
+	ownMockWebsocket.On("Dial").Return(nil)
+	ownMockWebsocket.On("SetURL", mock.Anything).Return(nil)
+	ownMockWebsocket.On("Close").Return()
+	ownMockWebsocket.On("OnMessage", mock.Anything, mock.Anything).Return()
+
+	// New Signaling with mock
+	client, err := signaling.New(&configViewer, signaling.WithSigner(ownMockSigner), signaling.WithWebsocketClient(ownMockWebsocket))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// if reconnect ICE server event
+	client.OnReconnectIceServer(func(msg *signaling.ReconnectIceServer) {
+		assert.Equal(t, "", msg.Payload)
+		c <- "done"
+	})
+
+	// if open event
+	client.OnOpen(func() {
+		ownMockWebsocket.onMessage(signaling.TextMessage, []byte(reconnectIceServerMessage))
+	})
+
+	// Signaling Open Connection
+	err = client.Open()
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	// Wait until done
+	if <-c != "done" {
+		t.Errorf("Unexpected error")
+	}
+}
+
+// Testing Events Go Away reconnects without reconnect policy
+func TestEventGoAway(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create channels for control flow
+	c := make(chan string)
+	d := make(chan string, 1)
+
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+	// Create mock WebSocket
+	ownMockWebsocket := &mockWebSocket{}
+	// Expected mock WebSocket functions
+	ownMockWebsocket.On("Dial").Return(nil)
+	ownMockWebsocket.On("SetURL", mock.Anything).Return(nil)
+	ownMockWebsocket.On("Close").Return()
+	ownMockWebsocket.On("OnMessage", mock.Anything, mock.Anything).Return()
+
+	// New Signaling with mock
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner), signaling.WithWebsocketClient(ownMockWebsocket))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// if go away event
+	client.OnGoAway(func(goAway *signaling.GoAway) {
+		d <- "done"
+	})
+
+	// if close event, it should never happen
+	client.OnClose(func() {
+		t.Errorf("Unexpected close")
+	})
+
+	// if open event
+	client.OnOpen(func() {
+		ownMockWebsocket.onMessage(signaling.TextMessage, []byte(goAwayMessage))
+	})
+
+	// if reconnected event
+	client.OnReconnected(func(attempt int) {
+		assert.Equal(t, 1, attempt)
+		ownMockWebsocket.AssertNumberOfCalls(t, "Close", 1)
+		ownMockSigner.AssertNumberOfCalls(t, "GetSignedURL", 2)
+		c <- "done"
+	})
+
+	// Signaling Open Connection
+	err = client.Open()
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	// Wait until done
+	if <-d != "done" {
+		t.Errorf("Unexpected error")
+	}
+	if <-c != "done" {
+		t.Errorf("Unexpected error")
+	}
+}
+
+// Testing default signer uses own credentials provider instead of config credentials
+func TestWithCredentialsProvider(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+	configMaster.CredentialsValue = &credentials.Value{AccessKeyID: "AKIDCONFIG", SecretAccessKey: "SECRET"}
+
+	// Keep signed url
+	signedURL := make(chan string, 1)
+	ownMockWebsocket := &mockWebSocket{}
+	ownMockWebsocket.On("Dial").Return(nil)
+	ownMockWebsocket.On("SetURL", mock.Anything).Run(func(args mock.Arguments) {
+		signedURL <- args.String(0)
+	}).Return(nil)
+	ownMockWebsocket.On("OnMessage", mock.Anything, mock.Anything).Return()
+
+	// New Signaling with credentials provider and default signer
+	provider := &credentials.StaticProvider{Value: credentials.Value{AccessKeyID: "AKIDPROVIDER", SecretAccessKey: "SECRET"}}
+	client, err := signaling.New(&configMaster, signaling.WithCredentialsProvider(provider), signaling.WithWebsocketClient(ownMockWebsocket))
+
+	// if something wrong happened
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer client.Close()
+	ownMockWebsocket.On("Close").Return()
+
+	// Signaling Open Connection
+	assert.NoError(t, client.Open())
+
+	// ASSERTS
+	u, err := url.Parse(<-signedURL)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(u.Query().Get("X-Amz-Credential"), "AKIDPROVIDER/"))
+}
+
+// Testing default signer uses region and credentials of aws-sdk-go-v2 config
+func TestWithAWSV2Config(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+	configMaster.Region = nil
+
+	// Keep signed url
+	signedURL := make(chan string, 1)
+	ownMockWebsocket := &mockWebSocket{}
+	ownMockWebsocket.On("Dial").Return(nil)
+	ownMockWebsocket.On("SetURL", mock.Anything).Run(func(args mock.Arguments) {
+		signedURL <- args.String(0)
+	}).Return(nil)
+	ownMockWebsocket.On("OnMessage", mock.Anything, mock.Anything).Return()
+	ownMockWebsocket.On("Close").Return()
+
+	// New Signaling with v2 config and default signer
+	awsConfig := awsV2.Config{
+		Region: "eu-west-1",
+		Credentials: awsV2.CredentialsProviderFunc(func(ctx context.Context) (awsV2.Credentials, error) {
+			return awsV2.Credentials{AccessKeyID: "AKIDV2", SecretAccessKey: "SECRET"}, nil
+		}),
+	}
+	client, err := signaling.New(&configMaster, signaling.WithAWSV2Config(awsConfig), signaling.WithWebsocketClient(ownMockWebsocket))
+
+	// if something wrong happened
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	// Signaling Open Connection
+	assert.NoError(t, client.Open())
+
+	// ASSERTS
+	u, err := url.Parse(<-signedURL)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(u.Query().Get("X-Amz-Credential"), "AKIDV2/"))
+	assert.Contains(t, u.Query().Get("X-Amz-Credential"), "/eu-west-1/kinesisvideo/")
+	assert.Nil(t, configMaster.Region)
+}
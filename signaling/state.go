@@ -0,0 +1,74 @@
+package signaling
+
+// Allowed signaling client state transitions
+//
+//	CLOSED -> CONNECTING          Open
+//	CONNECTING -> OPEN            websocket connection is open
+//	CONNECTING -> CLOSING         Close while connecting
+//	CONNECTING -> CLOSED          connection failed or pending reconnect cancelled
+//	OPEN -> CLOSING               Close
+//	OPEN -> CONNECTING            unexpected close, reconnecting
+//	OPEN -> CLOSED                unexpected close, reconnect disabled
+//	CLOSING -> CLOSED             websocket connection is closed
+var stateTransitions = map[ReadyStateType][]ReadyStateType{
+	StateClosed:     {StateConnecting},
+	StateConnecting: {StateOpen, StateClosing, StateClosed},
+	StateOpen:       {StateClosing, StateConnecting, StateClosed},
+	StateClosing:    {StateClosed},
+}
+
+// State returns current signaling client connection state
+func (sc *Client) State() ReadyStateType {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.readyState
+}
+
+// On State Change Event Function, triggered after every state transition
+func (sc *Client) OnStateChange(f func(old ReadyStateType, new ReadyStateType)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.onStateChange = f
+}
+
+// Change state when current one is any of input states and transition is allowed,
+// return false if nothing changed
+func (sc *Client) transition(to ReadyStateType, from ...ReadyStateType) bool {
+	sc.mu.Lock()
+	old, ok := sc.transitionLocked(to, from...)
+	sc.mu.Unlock()
+
+	if ok {
+		sc.emitStateChange(old, to)
+	}
+	return ok
+}
+
+// Same as transition but lock must be held by caller, and so State Change Event must be
+// triggered by caller after unlock. It returns previous state
+func (sc *Client) transitionLocked(to ReadyStateType, from ...ReadyStateType) (ReadyStateType, bool) {
+	old := sc.readyState
+	if !containsState(from, old) || !containsState(stateTransitions[old], to) {
+		return old, false
+	}
+	sc.readyState = to
+	switch {
+	// Opened again, event stream publishes may block until next Close
+	case old == StateClosed:
+		sc.closing = make(chan struct{})
+	// CLOSED without Close, like after a failed Open, event stream publishes must not block either
+	case to == StateClosed:
+		sc.unblockEventsLocked()
+	}
+	return old, true
+}
+
+// Check if state is in list
+func containsState(states []ReadyStateType, state ReadyStateType) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package signaling_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Testing state transitions from Open to Close
+func TestStateTransitions(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create channel for control flow
+	c := make(chan string)
+
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+	// New Signaling with mock
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner), signaling.WithWebsocketClient(newMockWebSocket()))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Initial state
+	assert.Equal(t, signaling.StateClosed, client.State())
+
+	// if state change event
+	var mu sync.Mutex
+	transitions := []string{}
+	client.OnStateChange(func(old signaling.ReadyStateType, new signaling.ReadyStateType) {
+		mu.Lock()
+		defer mu.Unlock()
+		transitions = append(transitions, string(old)+"->"+string(new))
+	})
+
+	// if open event
+	client.OnOpen(func() {
+		c <- "open"
+	})
+
+	// if close event
+	client.OnClose(func() {
+		c <- "close"
+	})
+
+	// Signaling Open Connection
+	err = client.Open()
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Wait until open
+	assert.Equal(t, "open", <-c)
+	assert.Equal(t, signaling.StateOpen, client.State())
+
+	// Close and wait until closed
+	go client.Close()
+	assert.Equal(t, "close", <-c)
+	assert.Equal(t, signaling.StateClosed, client.State())
+
+	// ASSERTS
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"CLOSED->CONNECTING", "CONNECTING->OPEN", "OPEN->CLOSING", "CLOSING->CLOSED"}, transitions)
+}
+
+// Testing state goes back to CLOSED when Open fails
+func TestStateOpenFailed(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create channel for control flow
+	c := make(chan string)
+
+	// Create mock Signer failing
+	ownMockSigner := &mockSigner{}
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return("", fmt.Errorf("MockError"))
+
+	// New Signaling with mock
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner), signaling.WithWebsocketClient(newMockWebSocket()))
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Back to CLOSED after failed connection
+	client.OnStateChange(func(old signaling.ReadyStateType, new signaling.ReadyStateType) {
+		if new == signaling.StateClosed {
+			c <- string(old)
+		}
+	})
+
+	// if error event
+	client.OnError(func(err error) {})
+
+	// Signaling Open Connection
+	err = client.Open()
+
+	// if something wrong happened
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// ASSERTS
+	assert.Equal(t, string(signaling.StateConnecting), <-c)
+	assert.Equal(t, signaling.StateClosed, client.State())
+}
+
+// Testing signaling client can be used from many goroutines
+func TestStateConcurrentUse(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client
+	ownMockWebsocket := newMockWebSocket()
+	client := openMasterWithMock(t, ownMockWebsocket)
+
+	// Count Ice Candidate events
+	var mu sync.Mutex
+	iceCandidates := 0
+	client.OnIceCandidate(func(iceCandidate *string, clientID *string) {
+		mu.Lock()
+		defer mu.Unlock()
+		iceCandidates++
+	})
+	client.OnSdpOffer(func(offer *string, remoteClientID *string) {})
+
+	// Receive messages, send messages and read state at the same time
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		remoteClientID := fmt.Sprintf("client%d", i)
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			ownMockWebsocket.onMessage(signaling.TextMessage, []byte(`{"messageType":"ICE_CANDIDATE","messagePayload":"eyJjYW5kaWRhdGUiOiJ1cGQgMTAuMTExLjM0Ljg4Iiwic2RwTWlkIjoiMSIsInNkcE1MaW5lSW5kZXgiOjF9","senderClientId":"`+remoteClientID+`"}`))
+			ownMockWebsocket.onMessage(signaling.TextMessage, []byte(`{"messageType":"SDP_OFFER","messagePayload":"eyJzZHAiOiJvZmZlcj0gdHJ1ZVxudmlkZW89IHRydWUiLCJ0eXBlIjoib2ZmZXIifQ==","senderClientId":"`+remoteClientID+`"}`))
+		}()
+		go func() {
+			defer wg.Done()
+			client.SendIceCandidate(ICECandidate, &remoteClientID)
+		}()
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, signaling.StateOpen, client.State())
+			client.OnError(func(err error) {})
+		}()
+	}
+	wg.Wait()
+
+	// ASSERTS
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 10, iceCandidates)
+	ownMockWebsocket.AssertNumberOfCalls(t, "Send", 10)
+}
@@ -0,0 +1,201 @@
+package signaling
+
+import "time"
+
+// Default event stream buffer size
+const DefaultEventBuffer = 64
+
+// What to do when event stream buffer is full
+type EventPolicy int
+
+const (
+	// Drop new events, signaling client never waits for event stream consumer
+	EventPolicyDrop EventPolicy = iota
+	// Wait until there is room in buffer, signaling client stops reading messages meanwhile.
+	// Close stops waiting, events that do not fit are dropped until signaling client is opened again.
+	// Events are published without signaling client locks held, so consumer may send from its loop
+	EventPolicyBlock
+)
+
+// Event from signaling client event stream, use a type switch to handle it:
+// OpenEvent, CloseEvent, ErrorEvent, StateChangeEvent, SdpOfferEvent, SdpAnswerEvent,
+// IceCandidateEvent, StatusResponseEvent, GoAwayEvent, ReconnectIceServerEvent,
+// ReconnectingEvent, ReconnectedEvent, SessionChangeEvent, IceCandidateDroppedEvent,
+// CredentialsRotatedEvent or ClockSkewCorrectedEvent
+type Event interface {
+	isEvent()
+}
+
+// Connection to signaling service is open
+type OpenEvent struct{}
+
+// Connection to signaling service is closed
+type CloseEvent struct{}
+
+// Something wrong happened
+type ErrorEvent struct {
+	Err error // What happened
+}
+
+// Signaling client state changed
+type StateChangeEvent struct {
+	Old ReadyStateType // Previous state
+	New ReadyStateType // Current state
+}
+
+// Sdp offer received
+type SdpOfferEvent struct {
+	Offer    string // Sdp offer
+	ClientID string // Sender client id, empty when sent by master
+}
+
+// Sdp answer received
+type SdpAnswerEvent struct {
+	Answer   string // Sdp answer
+	ClientID string // Sender client id, empty when sent by master
+}
+
+// Ice candidate received, after sdp message of its sender
+type IceCandidateEvent struct {
+	IceCandidate string // Ice candidate
+	ClientID     string // Sender client id, empty when sent by master
+}
+
+// Status response received for a sent message
+type StatusResponseEvent struct {
+	Status StatusResponse // Status response
+}
+
+// Go away received, signaling client reconnects by itself after it
+type GoAwayEvent struct {
+	GoAway GoAway // Go away message
+}
+
+// Reconnect ICE server received
+type ReconnectIceServerEvent struct {
+	ReconnectIceServer ReconnectIceServer // Reconnect ICE server message
+}
+
+// Waiting for a reconnect attempt
+type ReconnectingEvent struct {
+	Attempt int           // Reconnect attempt
+	Delay   time.Duration // Wait before attempt
+}
+
+// Connection to signaling service is open after a reconnect attempt
+type ReconnectedEvent struct {
+	Attempt int // Successful reconnect attempt
+}
+
+// Viewer session created or changed
+type SessionChangeEvent struct {
+	Session Session // Viewer session
+}
+
+// Pending ice candidate dropped
+type IceCandidateDroppedEvent struct {
+	IceCandidate string // Ice candidate
+	ClientID     string // Sender client id, empty when sent by master
+	Reason       error  // ErrIceCandidateQueueFull, ErrIceCandidateExpired or ErrIceCandidateStale
+}
+
+// Default signer got new credentials, next connection is signed with them
+type CredentialsRotatedEvent struct {
+	AccessKeyID string    // Access key of new credentials
+	Expires     time.Time // When new credentials expire, zero if they do not expire
+}
+
+// Signing clock offset changed after a handshake rejected because of clock skew
+type ClockSkewCorrectedEvent struct {
+	Offset time.Duration // AWS clock minus local clock
+}
+
+func (OpenEvent) isEvent()                {}
+func (CloseEvent) isEvent()               {}
+func (ErrorEvent) isEvent()               {}
+func (StateChangeEvent) isEvent()         {}
+func (SdpOfferEvent) isEvent()            {}
+func (SdpAnswerEvent) isEvent()           {}
+func (IceCandidateEvent) isEvent()        {}
+func (StatusResponseEvent) isEvent()      {}
+func (GoAwayEvent) isEvent()              {}
+func (ReconnectIceServerEvent) isEvent()  {}
+func (ReconnectingEvent) isEvent()        {}
+func (ReconnectedEvent) isEvent()         {}
+func (SessionChangeEvent) isEvent()       {}
+func (IceCandidateDroppedEvent) isEvent() {}
+func (CredentialsRotatedEvent) isEvent()  {}
+func (ClockSkewCorrectedEvent) isEvent()  {}
+
+// Use own event stream buffer size and policy when it is full
+func WithEventBuffer(size int, policy EventPolicy) func(*Client) {
+	return func(sc *Client) {
+		sc.eventBuffer = size
+		sc.eventPolicy = policy
+	}
+}
+
+// Events returns signaling client event stream, an alternative to event functions. Events are
+// published only after first call, and event functions are still triggered. Stream is never closed,
+// signaling client can be opened again after CloseEvent
+func (sc *Client) Events() <-chan Event {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.events == nil {
+		sc.events = make(chan Event, sc.eventBuffer)
+	}
+	return sc.events
+}
+
+// DroppedEvents returns how many events were dropped because event stream buffer was full
+func (sc *Client) DroppedEvents() uint64 {
+	return sc.droppedEvents.Load()
+}
+
+// Publish event to event stream if there is a consumer
+func (sc *Client) publish(event Event) {
+	sc.mu.Lock()
+	events := sc.events
+	closing := sc.closing
+	sc.mu.Unlock()
+
+	// Nobody asked for event stream
+	if events == nil {
+		return
+	}
+
+	// Room in buffer
+	select {
+	case events <- event:
+		return
+	default:
+	}
+
+	// Wait for consumer, unless signaling client is being closed
+	if sc.eventPolicy == EventPolicyBlock {
+		select {
+		case events <- event:
+			return
+		case <-closing:
+		}
+	}
+	sc.droppedEvents.Add(1)
+}
+
+// Stop blocking event stream publishes, lock must be held by caller
+func (sc *Client) unblockEventsLocked() {
+	select {
+	case <-sc.closing:
+	default:
+		close(sc.closing)
+	}
+}
+
+// Value of optional string, empty when nil
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
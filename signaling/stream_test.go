@@ -0,0 +1,382 @@
+package signaling_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signaling"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// New master signaling client over input mock WebSocket, not opened
+func newMasterWithMock(t *testing.T, ownMockWebsocket *mockWebSocket, options ...func(*signaling.Client)) *signaling.Client {
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+	// New Signaling with mock
+	options = append(options, signaling.WithSigner(ownMockSigner), signaling.WithWebsocketClient(ownMockWebsocket))
+	client, err := signaling.New(&configMaster, options...)
+
+	// if something wrong happened
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return client
+}
+
+// Wait for next event that is not a state or session change
+func nextEvent(t *testing.T, events <-chan signaling.Event) signaling.Event {
+	for {
+		select {
+		case event := <-events:
+			switch event.(type) {
+			case signaling.StateChangeEvent, signaling.SessionChangeEvent:
+				continue
+			}
+			return event
+		case <-time.After(time.Second):
+			t.Fatalf("Event not received")
+			return nil
+		}
+	}
+}
+
+// Testing events are published to event stream without event functions
+func TestEvents(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// New client with event stream
+	ownMockWebsocket := newMockWebSocket()
+	client := newMasterWithMock(t, ownMockWebsocket)
+	events := client.Events()
+
+	// Signaling Open Connection
+	assert.NoError(t, client.OpenContext(context.Background()))
+	assert.Equal(t, signaling.OpenEvent{}, nextEvent(t, events))
+
+	// Receive messages
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(iceCandidateViewerMessage))
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(sdpOfferViewerMessage))
+	ownMockWebsocket.onMessage(signaling.TextMessage, []byte(statusResponseMessage))
+
+	// ASSERTS
+	assert.Equal(t, signaling.SdpOfferEvent{Offer: SDPOffer, ClientID: clientID}, nextEvent(t, events))
+	assert.Equal(t, signaling.IceCandidateEvent{IceCandidate: ICECandidate, ClientID: clientID}, nextEvent(t, events))
+	statusEvent, ok := nextEvent(t, events).(signaling.StatusResponseEvent)
+	assert.True(t, ok)
+	assert.Equal(t, "400", statusEvent.Status.StatusCode)
+
+	// Close
+	client.Close()
+	assert.Equal(t, signaling.CloseEvent{}, nextEvent(t, events))
+	assert.Equal(t, uint64(0), client.DroppedEvents())
+}
+
+// Testing events are dropped when event stream buffer is full
+func TestEventsDropPolicy(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// New client with a single event buffer
+	client := newMasterWithMock(t, newMockWebSocket(), signaling.WithEventBuffer(1, signaling.EventPolicyDrop))
+	events := client.Events()
+
+	// Signaling Open Connection, it publishes two state changes and open
+	assert.NoError(t, client.OpenContext(context.Background()))
+
+	// ASSERTS
+	assert.Equal(t, signaling.StateChangeEvent{Old: signaling.StateClosed, New: signaling.StateConnecting}, <-events)
+	assert.Len(t, events, 0)
+	assert.Equal(t, uint64(2), client.DroppedEvents())
+}
+
+// Testing signaling client waits for event stream consumer
+func TestEventsBlockPolicy(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// New client with unbuffered event stream
+	client := newMasterWithMock(t, newMockWebSocket(), signaling.WithEventBuffer(0, signaling.EventPolicyBlock))
+	events := client.Events()
+
+	// Consume events until open
+	received := make(chan []signaling.Event)
+	go func() {
+		list := []signaling.Event{}
+		for event := range events {
+			list = append(list, event)
+			if _, ok := event.(signaling.OpenEvent); ok {
+				received <- list
+				return
+			}
+		}
+	}()
+
+	// Signaling Open Connection
+	assert.NoError(t, client.OpenContext(context.Background()))
+
+	// ASSERTS
+	assert.Equal(t, []signaling.Event{
+		signaling.StateChangeEvent{Old: signaling.StateClosed, New: signaling.StateConnecting},
+		signaling.StateChangeEvent{Old: signaling.StateConnecting, New: signaling.StateOpen},
+		signaling.OpenEvent{},
+	}, <-received)
+	assert.Equal(t, uint64(0), client.DroppedEvents())
+}
+
+// Testing event stream consumer can close signaling client while buffer is full
+func TestEventsBlockPolicyCloseFromConsumer(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Open client, then ask for event stream with a single event buffer
+	ownMockWebsocket := newMockWebSocket()
+	client := newMasterWithMock(t, ownMockWebsocket, signaling.WithEventBuffer(1, signaling.EventPolicyBlock))
+	client.OnSdpOffer(func(offer *string, remoteClientID *string) {})
+	assert.NoError(t, client.OpenContext(context.Background()))
+	events := client.Events()
+
+	// Signaling service sends offers faster than they are consumed
+	read := make(chan struct{})
+	go func() {
+		defer close(read)
+		for i := 0; i < 3; i++ {
+			ownMockWebsocket.onMessage(signaling.TextMessage, sdpOfferFrom("viewer"+strconv.Itoa(i)))
+		}
+	}()
+
+	// Consumer closes client on first event, buffer is full meanwhile
+	closed := make(chan struct{})
+	go func() {
+		<-events
+		assert.Eventually(t, func() bool { return len(events) == 1 }, time.Second, time.Millisecond)
+		client.Close()
+		close(closed)
+	}()
+
+	// ASSERTS
+	for _, c := range []chan struct{}{closed, read} {
+		select {
+		case <-c:
+		case <-time.After(time.Second):
+			t.Fatalf("Signaling client deadlocked")
+		}
+	}
+	assert.Equal(t, signaling.StateClosed, client.State())
+	assert.Greater(t, client.DroppedEvents(), uint64(0))
+}
+
+// Testing event stream consumer can send while buffer is full and connection replace is pending
+func TestEventsBlockPolicySendFromConsumerDuringSwap(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// First connection fails its send with an Error Event of websocket client
+	sendErr := errors.New("MockError")
+	sending := make(chan struct{})
+	firstClosed := make(chan struct{})
+	first := &mockWebSocket{}
+	first.On("Dial").Return(nil)
+	first.On("SetURL", mock.Anything).Return(nil)
+	first.On("OnMessage", mock.Anything, mock.Anything).Return()
+	first.On("Close").Return().Run(func(args mock.Arguments) {
+		close(firstClosed)
+	}).Once()
+	first.On("Send", mock.Anything, mock.Anything).Return(sendErr).Run(func(args mock.Arguments) {
+		close(sending)
+		first.onError(sendErr)
+	})
+
+	// Second connection is dialed when test releases it
+	release := make(chan struct{})
+	second := &mockWebSocket{}
+	second.On("Dial").Return(nil).Run(func(args mock.Arguments) {
+		<-release
+	})
+	second.On("SetURL", mock.Anything).Return(nil)
+	second.On("OnMessage", mock.Anything, mock.Anything).Return()
+	second.On("Close").Return()
+	second.On("Send", mock.Anything, mock.Anything).Return(nil)
+
+	// Mock WebSocket factory
+	created := 0
+	factory := func() signaling.WebSocketClientI {
+		created++
+		if created == 1 {
+			return first
+		}
+		return second
+	}
+
+	// Create mock Signer
+	ownMockSigner := &mockSigner{}
+	// Expected GetSignedURL function
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+
+	// Open client, then ask for event stream with a single event buffer
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner), signaling.WithWebsocketClientFactory(factory),
+		signaling.WithEventBuffer(1, signaling.EventPolicyBlock))
+
+	// if something wrong happened
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	assert.NoError(t, client.OpenContext(context.Background()))
+	events := client.Events()
+
+	// Consumer sends from its loop once test resumes it, then drains event stream
+	received := make(chan struct{})
+	resume := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	consumerSent := make(chan error, 1)
+	go func() {
+		<-events
+		close(received)
+		<-resume
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		consumerSent <- client.SendSdpAnswerContext(ctx, SDPAnswer, &clientID)
+		for {
+			select {
+			case <-events:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// Go away is received by consumer and starts replacing connection, next message fills buffer
+	first.onMessage(signaling.TextMessage, []byte(goAwayMessage))
+	<-received
+	first.onMessage(signaling.TextMessage, []byte(reconnectIceServerMessage))
+
+	// Send over first connection waits for consumer to publish its Error Event
+	sent := make(chan error, 1)
+	go func() {
+		sent <- client.SendSdpOfferContext(context.Background(), SDPOffer, &clientID)
+	}()
+	<-sending
+
+	// Second connection is open, replace waits for send over first one
+	close(release)
+	time.Sleep(20 * time.Millisecond)
+	close(resume)
+
+	// ASSERTS
+	select {
+	case err := <-consumerSent:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Signaling client deadlocked")
+	}
+	select {
+	case err := <-sent:
+		assert.ErrorIs(t, err, sendErr)
+	case <-time.After(time.Second):
+		t.Fatalf("Signaling client deadlocked")
+	}
+	second.AssertCalled(t, "Send", mock.Anything, mock.Anything)
+	select {
+	case <-firstClosed:
+	case <-time.After(time.Second):
+		t.Fatalf("Replaced connection was not closed")
+	}
+}
+
+// Testing block policy does not wait for consumer once a failed Open leaves client CLOSED
+func TestEventsBlockPolicyFailedOpen(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Create mock Signer failing
+	ownMockSigner := &mockSigner{}
+	ownMockSigner.On("GetSignedURL", mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("MockError"))
+
+	// New Signaling with mock, event stream buffer fits state change and error events only
+	client, err := signaling.New(&configMaster, signaling.WithSigner(ownMockSigner), signaling.WithWebsocketClient(newMockWebSocket()),
+		signaling.WithEventBuffer(2, signaling.EventPolicyBlock))
+
+	// if something wrong happened
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	events := client.Events()
+
+	// Signaling Open Connection, nobody reads event stream
+	opened := make(chan error, 1)
+	go func() {
+		opened <- client.OpenContext(context.Background())
+	}()
+
+	// ASSERTS
+	select {
+	case err := <-opened:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatalf("Signaling client deadlocked")
+	}
+	assert.Equal(t, signaling.StateClosed, client.State())
+	assert.Len(t, events, 2)
+	assert.Equal(t, uint64(1), client.DroppedEvents())
+}
+
+// Session credentials provider, first credentials expire soon
+type expiringProvider struct {
+	credentials.Expiry
+	mu       sync.Mutex
+	retrieve int
+}
+
+// Retrieve first or refreshed credentials
+func (p *expiringProvider) Retrieve() (credentials.Value, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.retrieve++
+	if p.retrieve == 1 {
+		p.SetExpiration(time.Now().Add(time.Minute), 0)
+		return credentials.Value{AccessKeyID: "ASIAFIRST", SecretAccessKey: "SECRET", SessionToken: "TOKEN"}, nil
+	}
+	p.SetExpiration(time.Now().Add(time.Hour), 0)
+	return credentials.Value{AccessKeyID: "ASIASECOND", SecretAccessKey: "SECRET", SessionToken: "TOKEN"}, nil
+}
+
+// Testing credentials refreshed by default signer are published to event stream
+func TestEventsCredentialsRotated(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// New client with default signer and expiring credentials
+	ownMockWebsocket := newMockWebSocket()
+	client, err := signaling.New(&configMaster, signaling.WithCredentialsProvider(&expiringProvider{}),
+		signaling.WithWebsocketClient(ownMockWebsocket))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	events := client.Events()
+
+	// Signaling Open Connection, signature starts credentials refresh
+	assert.NoError(t, client.OpenContext(context.Background()))
+
+	// Refresh may finish before connection is open
+	var rotated signaling.CredentialsRotatedEvent
+	for i := 0; i < 2; i++ {
+		if event, ok := nextEvent(t, events).(signaling.CredentialsRotatedEvent); ok {
+			rotated = event
+		}
+	}
+
+	// ASSERTS
+	assert.Equal(t, "ASIASECOND", rotated.AccessKeyID)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), rotated.Expires, time.Minute)
+	client.Close()
+}
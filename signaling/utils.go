@@ -10,3 +10,6 @@ func RandSeq(n int) string {
 	}
 	return val
 }
+
+// Random generator for non-crypto usage like backoff jitter
+var globalMathRandomGenerator = randutil.NewMathRandomGenerator()
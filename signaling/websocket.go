@@ -1,5 +1,7 @@
 package signaling
 
+import "context"
+
 // WebSocket API provides an interface to enable mock
 type WebSocketClientI interface {
 	OnClose(func())
@@ -12,5 +14,15 @@ type WebSocketClientI interface {
 	SetURL(string) error
 }
 
+// Optional WebSocket API to send data honouring context deadline and cancellation
+type WebSocketContextSender interface {
+	SendContext(context.Context, int, []byte) error
+}
+
+// Optional WebSocket API to dial honouring context deadline and cancellation
+type WebSocketContextDialer interface {
+	DialContext(context.Context) error
+}
+
 // For using webosocket text msg
 const TextMessage int = 1
@@ -1,35 +1,57 @@
 package signaling
 
 import (
+	"context"
 	"errors"
+	"io"
+	"net/http"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// Error when websocket client is used without an open connection
+var errNoConnection = errors.New("there is no open connection")
+
+// Default timeout for websocket opening handshake
+const DefaultHandshakeTimeout = 10 * time.Second
+
+// Max handshake response body kept in a HandshakeError
+const maxHandshakeBody = 4 * 1024
+
 // WebSocket Gorilla Client implementation
 type WebSocketClient struct {
+	HandshakeTimeout time.Duration // Timeout for opening handshake, DefaultHandshakeTimeout when 0
+
 	url      *string
 	conn     *websocket.Conn
 	isClosed bool
 	onClose  func()
 	onOpen   func()
 	onError  func(err error)
-	mu       sync.Mutex
+	mu       sync.Mutex // Connections support one concurrent writer
+	stateMu  sync.Mutex // Protect url, connection, state and event functions
 }
 
 // On Open Event Function
 func (ws *WebSocketClient) OnOpen(f func()) {
+	ws.stateMu.Lock()
+	defer ws.stateMu.Unlock()
 	ws.onOpen = f
 }
 
 // On Close Event Function
 func (ws *WebSocketClient) OnClose(f func()) {
+	ws.stateMu.Lock()
+	defer ws.stateMu.Unlock()
 	ws.onClose = f
 }
 
 // OnError Event Function
 func (ws *WebSocketClient) OnError(f func(err error)) {
+	ws.stateMu.Lock()
+	defer ws.stateMu.Unlock()
 	ws.onError = f
 }
 
@@ -40,16 +62,23 @@ func (ws *WebSocketClient) OnMessage(dial chan string, f func(messageType int, d
 		// wait until dial finish ok
 		// ensures that conn has courage before waiting for msgs
 		<-dial
+
+		// Dial failed, nothing to read
+		conn, isClosed := ws.connection()
+		if conn == nil || isClosed {
+			return
+		}
+
 		for {
-			messageType, message, err := ws.conn.ReadMessage()
+			messageType, message, err := conn.ReadMessage()
 			if err != nil {
-				// if it is error when is Closed then exit
-				if ws.isClosed {
+				// if it is error when is Closed or it was replaced by a new dial then exit
+				if current, isClosed := ws.connection(); isClosed || current != conn {
 					return
 				}
 
 				// Error Event triggered
-				ws.onError(err)
+				ws.emitError(&TransportError{Op: "read", Err: err})
 
 				// Close Websocket connection
 				ws.Close()
@@ -62,61 +91,158 @@ func (ws *WebSocketClient) OnMessage(dial chan string, f func(messageType int, d
 
 // Open connection to websocket
 func (ws *WebSocketClient) Dial() error {
+	return ws.DialContext(context.Background())
+}
+
+// Open connection to websocket, dial is aborted when context is done
+func (ws *WebSocketClient) DialContext(ctx context.Context) error {
+	ws.stateMu.Lock()
+	url := ws.url
+	ws.stateMu.Unlock()
+
+	// Default dialer with own handshake timeout
+	dialer := *websocket.DefaultDialer
+	dialer.HandshakeTimeout = ws.HandshakeTimeout
+	if dialer.HandshakeTimeout == 0 {
+		dialer.HandshakeTimeout = DefaultHandshakeTimeout
+	}
+
 	// Try to connect
-	conn, _, err := websocket.DefaultDialer.Dial(*ws.url, nil)
+	conn, resp, err := dialer.DialContext(ctx, *url, nil)
 	// Something wrong?
 	if err != nil {
+		// Handshake answered without upgrade
+		if resp != nil {
+			err = clockSkewError(newHandshakeError(resp, err))
+		}
+		err = &TransportError{Op: "dial", Err: err}
 		// Error Event triggered
-		ws.onError(err)
+		ws.emitError(err)
 		return err
 	}
+
+	ws.stateMu.Lock()
 	ws.conn = conn
+	ws.isClosed = false
+	onOpen := ws.onOpen
+	ws.stateMu.Unlock()
+
 	// Open Event triggered
-	ws.onOpen()
+	if onOpen != nil {
+		onOpen()
+	}
 	return nil
 }
 
 // Function to send data to websocket
 func (ws *WebSocketClient) Send(msgType int, data []byte) error {
-	// Connections support one concurrent writer
-	ws.mu.Lock()
-	defer ws.mu.Unlock()
-	err := ws.conn.WriteMessage(msgType, data)
+	return ws.SendContext(context.Background(), msgType, data)
+}
+
+// Function to send data to websocket using context deadline as write deadline. Connection is closed
+// when write fails, a timed out write leaves it unusable, so Close Event is triggered
+func (ws *WebSocketClient) SendContext(ctx context.Context, msgType int, data []byte) error {
+	// Caller gave up
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	conn, err := ws.write(ctx, msgType, data)
+	if conn == nil {
+		return &TransportError{Op: "write", Err: err}
+	}
 	// Something wrong?
 	if err != nil {
+		err = &TransportError{Op: "write", Err: err}
 		// Error Event triggered
-		ws.onError(err)
+		ws.emitError(err)
+		// Close broken connection, unless it was replaced by a new dial meanwhile
+		if current, _ := ws.connection(); current == conn {
+			ws.Close()
+		}
 		return err
 	}
 	return nil
 }
 
+// Write data to current connection, it returns connection used for it
+func (ws *WebSocketClient) write(ctx context.Context, msgType int, data []byte) (*websocket.Conn, error) {
+	// Connections support one concurrent writer
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	conn, _ := ws.connection()
+	if conn == nil {
+		return nil, errNoConnection
+	}
+
+	// Without deadline write blocks as long as it needs
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		return conn, err
+	}
+	defer conn.SetWriteDeadline(time.Time{})
+
+	return conn, conn.WriteMessage(msgType, data)
+}
+
 // Close Function do websocket close gratefully
 func (ws *WebSocketClient) Close() {
-	// Check if it calls when is closed
-	if ws.isClosed {
+	ws.stateMu.Lock()
+	// Check if it calls when is closed or never opened
+	if ws.isClosed || ws.conn == nil {
+		ws.stateMu.Unlock()
 		return
 	}
 
 	ws.isClosed = true
+	conn := ws.conn
+	onClose := ws.onClose
+	ws.stateMu.Unlock()
 
 	// Websocket connection close
-	ws.conn.Close()
+	conn.Close()
 
 	// Don't generate the closing event if there is no associated function
-	if ws.onClose != nil {
-		// Open Event triggered
-		ws.onClose()
+	if onClose != nil {
+		// Close Event triggered
+		onClose()
 	}
 }
 
 // Set url of Websocket
 func (ws *WebSocketClient) SetURL(url string) error {
-	// Check if conn exists
-	if ws.conn != nil {
+	ws.stateMu.Lock()
+	defer ws.stateMu.Unlock()
+
+	// Check if an open conn exists, a closed one can be dialed again
+	if ws.conn != nil && !ws.isClosed {
 		return errors.New("you already have an open connection")
 	}
 	// Assign url value
 	ws.url = &url
 	return nil
 }
+
+// Get current connection and whether it was closed
+func (ws *WebSocketClient) connection() (*websocket.Conn, bool) {
+	ws.stateMu.Lock()
+	defer ws.stateMu.Unlock()
+	return ws.conn, ws.isClosed
+}
+
+// Trigger Error Event if there is a function for it
+func (ws *WebSocketClient) emitError(err error) {
+	ws.stateMu.Lock()
+	f := ws.onError
+	ws.stateMu.Unlock()
+	if f != nil {
+		f(err)
+	}
+}
+
+// Handshake error with status code, headers and first bytes of body of handshake response
+func newHandshakeError(resp *http.Response, err error) *HandshakeError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxHandshakeBody))
+	return &HandshakeError{StatusCode: resp.StatusCode, Header: resp.Header, Body: body, Err: err}
+}
@@ -0,0 +1,26 @@
+package signer
+
+import (
+	"context"
+	"time"
+)
+
+// AWS Credentials for a signature, SDK agnostic
+type Credentials struct {
+	AccessKeyID     string    // AWS Access key ID
+	SecretAccessKey string    // AWS Secret Access Key
+	SessionToken    string    // AWS Session Token, empty for long term credentials
+	CanExpire       bool      // Credentials expire, like assumed role ones
+	Expires         time.Time // When credentials expire, ignored if they can not expire
+}
+
+// Credentials Provider provides an SDK agnostic interface to retrieve AWS Credentials
+type CredentialsProviderI interface {
+	// Return current credentials, they are retrieved again when they expire
+	Retrieve(ctx context.Context) (Credentials, error)
+}
+
+// Retrieve returns credentials as they are, so static credentials are a provider too
+func (c Credentials) Retrieve(ctx context.Context) (Credentials, error) {
+	return c, nil
+}
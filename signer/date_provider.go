@@ -3,11 +3,22 @@ package signer
 import "time"
 
 type DateProvier struct {
+	// Offset added to local clock as a count of milliseconds, not a real duration:
+	// ClockOffset: 1000 is one second, ClockOffset: time.Second is a million seconds.
+	//
+	// Deprecated: Use Offset, a real duration. Both offsets are added when they are set.
 	ClockOffset time.Duration
+
+	Offset time.Duration // Offset added to local clock, AWS clock minus local clock
 }
 
 // GetDate using config offset
 func (d *DateProvier) GetDate() *time.Time {
-	dateOffset := time.Now().Add(d.ClockOffset * time.Millisecond)
+	dateOffset := time.Now().Add(d.TotalOffset())
 	return &dateOffset
 }
+
+// TotalOffset returns offset added to local clock, sum of both offsets
+func (d *DateProvier) TotalOffset() time.Duration {
+	return d.ClockOffset*time.Millisecond + d.Offset
+}
@@ -0,0 +1,32 @@
+package signer_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer"
+	"github.com/stretchr/testify/assert"
+)
+
+// Check clock offset is counted in milliseconds and offset as a duration
+func TestDateProviderOffsets(t *testing.T) {
+	tests := map[string]struct {
+		provider signer.DateProvier
+		offset   time.Duration
+	}{
+		"clock offset": {provider: signer.DateProvier{ClockOffset: 60000}, offset: time.Minute},
+		"offset":       {provider: signer.DateProvier{Offset: time.Minute}, offset: time.Minute},
+		"both":         {provider: signer.DateProvier{ClockOffset: 60000, Offset: -30 * time.Second}, offset: 30 * time.Second},
+	}
+
+	for name, test := range tests {
+		before := time.Now()
+		date := test.provider.GetDate()
+		after := time.Now()
+
+		// ASSERTS
+		assert.Equal(t, test.offset, test.provider.TotalOffset(), name)
+		assert.False(t, date.Before(before.Add(test.offset)), name)
+		assert.False(t, date.After(after.Add(test.offset)), name)
+	}
+}
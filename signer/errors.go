@@ -0,0 +1,49 @@
+package signer
+
+import "errors"
+
+// Signer errors, match them with errors.Is
+var (
+	// Endpoint can not be signed
+	ErrInvalidEndpoint = errors.New("invalid endpoint")
+	// Presigned url expiry is not between 1 second and 7 days
+	ErrInvalidExpires = errors.New("presigned url expiry must be between 1 second and 7 days")
+	// Credentials do not exist or they are expired
+	ErrCredentialsExpired = errors.New("credentials for sign invalid because they are non-existent or expired")
+)
+
+// Error for an endpoint that can not be signed
+type EndpointError struct {
+	Endpoint string // Endpoint to sign
+	Reason   string // What is wrong
+}
+
+// Error message with endpoint and reason
+func (e *EndpointError) Error() string {
+	return "Endpoint '" + e.Endpoint + "' " + e.Reason
+}
+
+// EndpointError is an ErrInvalidEndpoint
+func (e *EndpointError) Is(target error) bool {
+	return target == ErrInvalidEndpoint
+}
+
+// Error for credentials that can not be used to sign
+type CredentialsError struct {
+	Err error // Credentials provider error
+}
+
+// Error message, same for every provider error
+func (e *CredentialsError) Error() string {
+	return ErrCredentialsExpired.Error()
+}
+
+// CredentialsError is an ErrCredentialsExpired
+func (e *CredentialsError) Is(target error) bool {
+	return target == ErrCredentialsExpired
+}
+
+// Unwrap returns credentials provider error
+func (e *CredentialsError) Unwrap() error {
+	return e.Err
+}
@@ -1,6 +1,8 @@
 package signer
 
 import (
+	"io"
+	"net/http"
 	"time"
 )
 
@@ -12,3 +14,9 @@ type APII interface {
 	// Return an enpoint url signed
 	GetSignedURL(endpoint string, queryParams QueryParams, date *time.Time) (string, error)
 }
+
+// Request Signer provides an interface to sign http requests with AWS Signature
+type RequestSignerI interface {
+	// Add Authorization header to request, body is the request payload
+	SignRequest(req *http.Request, body io.ReadSeeker, date *time.Time) error
+}
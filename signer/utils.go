@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"net/url"
 	"sort"
+	"strings"
 )
 
 // Util for merge two maps map[string]string into one map[string]string
@@ -34,30 +35,118 @@ func HMAC(key []byte, text string) []byte {
 	return sig.Sum(nil)
 }
 
-// Build from headers map the string with headers line by line
+// Build from headers map the canonical string with headers line by line, lowercased names sorted
+// and trimmed values
 func CreateHeadersString(headers map[string]string) string {
-	var headersString = ""
-	for k := range headers {
-		headersString = headersString + k + ":" + headers[k] + "\n"
+	canonicalHeaders := lowerKeys(headers)
+	var headersString strings.Builder
+	for _, k := range SortedKeys(canonicalHeaders) {
+		headersString.WriteString(k + ":" + CanonicalHeaderValue([]string{canonicalHeaders[k]}) + "\n")
 	}
-	return headersString
+	return headersString.String()
 }
 
-// Build from QueryParams the URI encoded string
-func CreateQueryString(queryParams QueryParams) string {
-	keys := make([]string, 0, len(queryParams))
+// Build from headers map the signed headers string, lowercased names sorted and separated by ;
+func CreateSignedHeadersString(headers map[string]string) string {
+	return strings.Join(SortedKeys(lowerKeys(headers)), ";")
+}
 
-	for k := range queryParams {
+// Header values with trimmed and collapsed spaces, comma separated
+func CanonicalHeaderValue(values []string) string {
+	trimmed := make([]string, 0, len(values))
+	for _, v := range values {
+		trimmed = append(trimmed, strings.Join(strings.Fields(v), " "))
+	}
+	return strings.Join(trimmed, ",")
+}
+
+// Sorted keys of a map[string]string
+func SortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
 		keys = append(keys, k)
 	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Copy of a map[string]string with lowercased keys
+func lowerKeys(m map[string]string) map[string]string {
+	lowered := make(map[string]string, len(m))
+	for k, v := range m {
+		lowered[strings.ToLower(k)] = v
+	}
+	return lowered
+}
+
+// Build from QueryParams the URI encoded canonical string
+func CreateQueryString(queryParams QueryParams) string {
+	values := url.Values{}
+	for k, v := range queryParams {
+		values.Set(k, v)
+	}
+	return CreateCanonicalQueryString(values)
+}
+
+// Build from query values, keys may be repeated, the URI encoded canonical string. Pairs are sorted
+// by encoded key and then by encoded value
+func CreateCanonicalQueryString(values url.Values) string {
+	pairs := make([][2]string, 0, len(values))
+	for k, vs := range values {
+		encodedKey := URIEncode(k)
+		for _, v := range vs {
+			pairs = append(pairs, [2]string{encodedKey, URIEncode(v)})
+		}
+	}
 
 	// If the same data is not ordered, it gives a different signature!!!
-	sort.Strings(keys)
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
 
-	sortedQueryParams := url.Values{}
-	for _, k := range keys {
-		sortedQueryParams.Add(k, queryParams[k])
+	encoded := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		encoded = append(encoded, pair[0]+"="+pair[1])
 	}
+	return strings.Join(encoded, "&")
+}
+
+// Encode every segment of an URI path, slashes are kept. Empty path is /
+func EncodeURIPath(path string) string {
+	if path == "" {
+		return "/"
+	}
+
+	segments := strings.Split(path, "/")
+	for i := range segments {
+		segments[i] = URIEncode(segments[i])
+	}
+	return strings.Join(segments, "/")
+}
+
+// Encode text as RFC 3986 does, every byte but unreserved characters A-Z, a-z, 0-9, -, _, . and ~
+// is encoded as %XY with uppercase hex digits
+func URIEncode(text string) string {
+	const hexDigits = "0123456789ABCDEF"
+
+	var encoded strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if isUnreserved(c) {
+			encoded.WriteByte(c)
+			continue
+		}
+		encoded.WriteByte('%')
+		encoded.WriteByte(hexDigits[c>>4])
+		encoded.WriteByte(hexDigits[c&15])
+	}
+	return encoded.String()
+}
 
-	return sortedQueryParams.Encode()
+// RFC 3986 unreserved character
+func isUnreserved(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~'
 }
@@ -0,0 +1,92 @@
+package signer_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer"
+	"github.com/stretchr/testify/assert"
+)
+
+// Check if text is encoded as RFC 3986 does
+func TestURIEncode(t *testing.T) {
+	tests := map[string]string{
+		"":                 "",
+		"AZaz09-_.~":       "AZaz09-_.~",
+		"a b":              "a%20b",
+		"a+b=c&d":          "a%2Bb%3Dc%26d",
+		"/path":            "%2Fpath",
+		"*!'()":            "%2A%21%27%28%29",
+		"é":                "%C3%A9",
+		"AKIA/20191201/us": "AKIA%2F20191201%2Fus",
+	}
+
+	// ASSERTS
+	for text, expected := range tests {
+		assert.Equal(t, expected, signer.URIEncode(text), text)
+	}
+}
+
+// Check if path segments are encoded and slashes are kept
+func TestEncodeURIPath(t *testing.T) {
+	tests := map[string]string{
+		"":                  "/",
+		"/":                 "/",
+		"/path/path/path":   "/path/path/path",
+		"/a b/c":            "/a%20b/c",
+		"/example%20space/": "/example%2520space/",
+		"/~user/a+b":        "/~user/a%2Bb",
+	}
+
+	// ASSERTS
+	for path, expected := range tests {
+		assert.Equal(t, expected, signer.EncodeURIPath(path), path)
+	}
+}
+
+// Check if query strings are canonical
+func TestCreateCanonicalQueryString(t *testing.T) {
+	tests := map[string]string{
+		"":                            "",
+		"Param2=value2&Param1=value1": "Param1=value1&Param2=value2",
+		"a=2&a=1&a=10":                "a=1&a=10&a=2",
+		"a=1&B=2":                     "B=2&a=1",
+		"a-b=1&a=2":                   "a=2&a-b=1",
+		"q=a+b&r=c%20d":               "q=a%20b&r=c%20d",
+		"e=":                          "e=",
+		"token=AQo%2Fab%2Bc%3D":       "token=AQo%2Fab%2Bc%3D",
+	}
+
+	for query, expected := range tests {
+		values, err := url.ParseQuery(query)
+
+		// ASSERTS
+		assert.NoError(t, err)
+		assert.Equal(t, expected, signer.CreateCanonicalQueryString(values), query)
+	}
+}
+
+// Check if query params are canonical
+func TestCreateQueryString(t *testing.T) {
+	queryParams := signer.QueryParams{"X-Amz-Date": "20191201T000000Z", "Action": "a b", "X-Amz-Credential": "AKIA/20191201"}
+
+	// ASSERTS
+	assert.Equal(t, "Action=a%20b&X-Amz-Credential=AKIA%2F20191201&X-Amz-Date=20191201T000000Z", signer.CreateQueryString(queryParams))
+}
+
+// Check if headers are canonical
+func TestCreateHeadersString(t *testing.T) {
+	headers := map[string]string{
+		"Host":         "example.amazonaws.com",
+		"My-Header2":   ` "a   b   c" `,
+		"my-header1":   "value1",
+		"X-Amz-Date":   "20150830T123600Z",
+		"Content-Type": "application/json",
+	}
+
+	// ASSERTS
+	assert.Equal(t, "content-type:application/json\nhost:example.amazonaws.com\nmy-header1:value1\nmy-header2:\"a b c\"\nx-amz-date:20150830T123600Z\n",
+		signer.CreateHeadersString(headers))
+	assert.Equal(t, "content-type;host;my-header1;my-header2;x-amz-date", signer.CreateSignedHeadersString(headers))
+	assert.Equal(t, "value2,value2,value1", signer.CanonicalHeaderValue([]string{"value2", " value2 ", "value1"}))
+}
@@ -0,0 +1,241 @@
+package v4
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/defaults"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+// Default EC2 instance metadata endpoint
+const DefaultIMDSEndpoint = "http://169.254.169.254"
+
+// Default timeout of EC2 instance metadata requests, metadata service is local when there is one
+const DefaultIMDSTimeout = time.Second
+
+// Retries of EC2 instance metadata requests, like aws-sdk-go defaults
+const imdsMaxRetries = 2
+
+// Default STS region, global endpoint
+const DefaultSTSRegion = "us-east-1"
+
+// Time before expiry when remote credentials are refreshed
+const credentialsExpiryWindow = 5 * time.Minute
+
+// Credentials providers configure
+type CredentialsConfig struct {
+	Region            string                               // AWS Region for STS requests
+	STSEndpoint       string                               // Own STS endpoint, regional one when empty
+	IMDSEndpoint      string                               // Own EC2 instance metadata endpoint
+	IMDSTimeout       time.Duration                        // Timeout of EC2 instance metadata requests
+	HTTPClient        *http.Client                         // Client for credentials requests
+	AssumeRoleOptions []func(*stscreds.AssumeRoleProvider) // Assume role optional parameters, like external id or duration
+}
+
+// Use input region for STS requests
+func WithSTSRegion(region string) func(*CredentialsConfig) {
+	return func(c *CredentialsConfig) {
+		c.Region = region
+	}
+}
+
+// Use own STS endpoint, like a VPC endpoint or a local stand-in
+func WithSTSEndpoint(endpoint string) func(*CredentialsConfig) {
+	return func(c *CredentialsConfig) {
+		c.STSEndpoint = endpoint
+	}
+}
+
+// Use own EC2 instance metadata endpoint, like a local stand-in
+func WithIMDSEndpoint(endpoint string) func(*CredentialsConfig) {
+	return func(c *CredentialsConfig) {
+		c.IMDSEndpoint = endpoint
+	}
+}
+
+// Use own timeout for EC2 instance metadata requests, DefaultIMDSTimeout by default
+func WithIMDSTimeout(timeout time.Duration) func(*CredentialsConfig) {
+	return func(c *CredentialsConfig) {
+		c.IMDSTimeout = timeout
+	}
+}
+
+// Use own http client for credentials requests, EC2 instance metadata requests keep their own timeout
+func WithCredentialsHTTPClient(httpClient *http.Client) func(*CredentialsConfig) {
+	return func(c *CredentialsConfig) {
+		c.HTTPClient = httpClient
+	}
+}
+
+// Use assume role optional parameters, like external id, session name or duration
+func WithAssumeRoleOptions(options ...func(*stscreds.AssumeRoleProvider)) func(*CredentialsConfig) {
+	return func(c *CredentialsConfig) {
+		c.AssumeRoleOptions = append(c.AssumeRoleOptions, options...)
+	}
+}
+
+// Add credentials as option, they can be refreshed by their provider
+func WithCredentials(creds *credentials.Credentials) func(*Signer) {
+	return func(sc *Signer) {
+		sc.Credentials = creds
+	}
+}
+
+// Add credentials provider as option
+func WithCredentialsProvider(provider credentials.Provider) func(*Signer) {
+	return func(sc *Signer) {
+		sc.Credentials = credentials.NewCredentials(provider)
+	}
+}
+
+// New credentials configure with default values and input options
+func newCredentialsConfig(options ...func(*CredentialsConfig)) *CredentialsConfig {
+	config := &CredentialsConfig{Region: DefaultSTSRegion, IMDSEndpoint: DefaultIMDSEndpoint, IMDSTimeout: DefaultIMDSTimeout}
+	for _, o := range options {
+		o(config)
+	}
+	return config
+}
+
+// AWS SDK config for credentials requests
+func (c *CredentialsConfig) awsConfig(creds *credentials.Credentials) *aws.Config {
+	config := aws.NewConfig().WithRegion(c.Region)
+	if c.STSEndpoint != "" {
+		config.WithEndpoint(c.STSEndpoint)
+	}
+	if c.HTTPClient != nil {
+		config.WithHTTPClient(c.HTTPClient)
+	}
+	if creds != nil {
+		config.WithCredentials(creds)
+	}
+	return config
+}
+
+// STS client signing with input credentials
+func (c *CredentialsConfig) stsClient(creds *credentials.Credentials) (*sts.STS, error) {
+	sess, err := session.NewSession(c.awsConfig(creds))
+	if err != nil {
+		return nil, err
+	}
+	return sts.New(sess), nil
+}
+
+// NewDefaultCredentials returns credentials of first provider that has them, in order:
+// 1.Env Provider
+// 2.Shared Credentials Provider
+// 3.Web Identity Provider, when AWS_WEB_IDENTITY_TOKEN_FILE and AWS_ROLE_ARN are set
+// 4.ECS Container Provider, when AWS_CONTAINER_CREDENTIALS_RELATIVE_URI or AWS_CONTAINER_CREDENTIALS_FULL_URI are set
+// 5.EC2 Instance Metadata Provider, otherwise. Its requests time out after DefaultIMDSTimeout unless
+// WithIMDSTimeout is used, so hosts without metadata service fail fast. Set AWS_EC2_METADATA_DISABLED to true to skip it
+func NewDefaultCredentials(options ...func(*CredentialsConfig)) (*credentials.Credentials, error) {
+	config := newCredentialsConfig(options...)
+
+	providers := []credentials.Provider{
+		&credentials.EnvProvider{},
+		&credentials.SharedCredentialsProvider{},
+	}
+
+	// Kubernetes service accounts, like EKS pods with IRSA
+	if tokenFile, roleARN := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), os.Getenv("AWS_ROLE_ARN"); tokenFile != "" && roleARN != "" {
+		stsClient, err := config.stsClient(credentials.AnonymousCredentials)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, stscreds.NewWebIdentityRoleProviderWithOptions(stsClient, roleARN,
+			os.Getenv("AWS_ROLE_SESSION_NAME"), stscreds.FetchTokenPath(tokenFile)))
+	}
+
+	// Containers have their own credentials endpoint, instances have metadata service
+	awsConfig := *defaults.Config()
+	if config.HTTPClient != nil {
+		awsConfig.HTTPClient = config.HTTPClient
+	}
+	if os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") != "" || os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "" {
+		providers = append(providers, defaults.RemoteCredProvider(awsConfig, defaults.Handlers()))
+	} else if !strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		providers = append(providers, &ec2rolecreds.EC2RoleProvider{
+			Client:       ec2metadata.NewClient(config.imdsConfig(awsConfig), defaults.Handlers(), config.IMDSEndpoint, ""),
+			ExpiryWindow: credentialsExpiryWindow,
+		})
+	}
+
+	return credentials.NewCredentials(&chainProvider{providers: providers}), nil
+}
+
+// AWS SDK config for EC2 instance metadata requests, they fail fast on hosts without metadata service
+func (c *CredentialsConfig) imdsConfig(awsConfig aws.Config) aws.Config {
+	httpClient := &http.Client{}
+	if awsConfig.HTTPClient != nil {
+		*httpClient = *awsConfig.HTTPClient
+	}
+	if c.IMDSTimeout > 0 && (httpClient.Timeout == 0 || httpClient.Timeout > c.IMDSTimeout) {
+		httpClient.Timeout = c.IMDSTimeout
+	}
+	awsConfig.HTTPClient = httpClient
+	awsConfig.MaxRetries = aws.Int(imdsMaxRetries)
+	// Own timeout is kept as is
+	awsConfig.EC2MetadataDisableTimeoutOverride = aws.Bool(true)
+	return awsConfig
+}
+
+// Chain of providers like credentials.ChainProvider, it tells expiry of the provider that
+// gave current credentials so they can be refreshed before they expire
+type chainProvider struct {
+	providers []credentials.Provider // Providers in priority order
+	current   credentials.Provider   // Provider of current credentials, nil if none
+}
+
+// Retrieve credentials of first provider that has them
+func (c *chainProvider) Retrieve() (credentials.Value, error) {
+	for _, p := range c.providers {
+		value, err := p.Retrieve()
+		if err == nil {
+			c.current = p
+			return value, nil
+		}
+	}
+	c.current = nil
+	return credentials.Value{}, credentials.ErrNoValidProvidersFoundInChain
+}
+
+// Expired state of current provider, true if there is none
+func (c *chainProvider) IsExpired() bool {
+	if c.current == nil {
+		return true
+	}
+	return c.current.IsExpired()
+}
+
+// Expiry of current provider, zero if it does not tell it
+func (c *chainProvider) ExpiresAt() time.Time {
+	if expirer, ok := c.current.(credentials.Expirer); ok {
+		return expirer.ExpiresAt()
+	}
+	return time.Time{}
+}
+
+// NewAssumeRoleCredentials returns credentials of input role, assumed with source credentials.
+// Source credentials can be assume role credentials too, for role chaining
+func NewAssumeRoleCredentials(source *credentials.Credentials, roleARN string, options ...func(*CredentialsConfig)) (*credentials.Credentials, error) {
+	config := newCredentialsConfig(options...)
+
+	stsClient, err := config.stsClient(source)
+	if err != nil {
+		return nil, err
+	}
+
+	assumeRoleOptions := append([]func(*stscreds.AssumeRoleProvider){func(p *stscreds.AssumeRoleProvider) {
+		p.ExpiryWindow = credentialsExpiryWindow
+	}}, config.AssumeRoleOptions...)
+	return stscreds.NewCredentialsWithClient(stsClient, roleARN, assumeRoleOptions...), nil
+}
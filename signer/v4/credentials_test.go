@@ -0,0 +1,279 @@
+package v4_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	signerV4 "github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer/v4"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/assert"
+)
+
+// Only credentials of test stand-ins, nothing from env or shared credentials file
+func isolateCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_ACCESS_KEY", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_SECRET_KEY", "")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent/credentials")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent/config")
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "")
+	t.Setenv("AWS_ROLE_ARN", "")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", "")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "")
+}
+
+// STS response with credentials named after assumed role
+func stsResponse(action string, roleARN string) string {
+	name := roleARN[strings.LastIndex(roleARN, "/")+1:]
+	return `<` + action + `Response xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><` + action + `Result><Credentials>` +
+		`<AccessKeyId>ASIA` + name + `</AccessKeyId><SecretAccessKey>secret-` + name + `</SecretAccessKey>` +
+		`<SessionToken>token-` + name + `</SessionToken><Expiration>2100-01-01T00:00:00Z</Expiration></Credentials>` +
+		`<AssumedRoleUser><Arn>` + roleARN + `/session</Arn><AssumedRoleId>AROA:session</AssumedRoleId></AssumedRoleUser>` +
+		`</` + action + `Result><ResponseMetadata><RequestId>request</RequestId></ResponseMetadata></` + action + `Response>`
+}
+
+// STS stand-in, it keeps access key of every signed request
+func newSTS(t *testing.T, signedBy *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			*signedBy = append(*signedBy, strings.Split(strings.TrimPrefix(auth, "AWS4-HMAC-SHA256 Credential="), "/")[0])
+		}
+		switch r.Form.Get("Action") {
+		case "AssumeRoleWithWebIdentity":
+			assert.Equal(t, "web-identity-token", r.Form.Get("WebIdentityToken"))
+			_, _ = w.Write([]byte(stsResponse("AssumeRoleWithWebIdentity", r.Form.Get("RoleArn"))))
+		case "AssumeRole":
+			_, _ = w.Write([]byte(stsResponse("AssumeRole", r.Form.Get("RoleArn"))))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+}
+
+// Check if credentials come from ECS container credentials endpoint
+func TestDefaultCredentialsContainer(t *testing.T) {
+	isolateCredentials(t)
+
+	// Container credentials endpoint stand-in
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "container-token", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"AccessKeyId":"ASIACONTAINER","SecretAccessKey":"secret","Token":"token","Expiration":"2100-01-01T00:00:00Z"}`))
+	}))
+	defer server.Close()
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", server.URL+"/credentials")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "container-token")
+
+	// Get credentials value from default chain
+	creds, err := signerV4.NewDefaultCredentials()
+	assert.NoError(t, err)
+	value, err := creds.Get()
+
+	// ASSERTS
+	assert.NoError(t, err)
+	assert.Equal(t, "ASIACONTAINER", value.AccessKeyID)
+	assert.Equal(t, "token", value.SessionToken)
+}
+
+// Check if credentials come from EC2 instance metadata
+func TestDefaultCredentialsInstanceMetadata(t *testing.T) {
+	isolateCredentials(t)
+
+	// Instance metadata stand-in, IMDSv2 token first
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			w.Header().Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", r.Header.Get("X-Aws-Ec2-Metadata-Token-Ttl-Seconds"))
+			_, _ = w.Write([]byte("imds-token"))
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/":
+			assert.Equal(t, "imds-token", r.Header.Get("X-Aws-Ec2-Metadata-Token"))
+			_, _ = w.Write([]byte("instance-role"))
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/instance-role":
+			_, _ = w.Write([]byte(`{"Code":"Success","Type":"AWS-HMAC","AccessKeyId":"ASIAINSTANCE","SecretAccessKey":"secret",` +
+				`"Token":"token","Expiration":"2100-01-01T00:00:00Z","LastUpdated":"2020-01-01T00:00:00Z"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// Get credentials value from default chain
+	creds, err := signerV4.NewDefaultCredentials(signerV4.WithIMDSEndpoint(server.URL))
+	assert.NoError(t, err)
+	value, err := creds.Get()
+
+	// ASSERTS
+	assert.NoError(t, err)
+	assert.Equal(t, "ASIAINSTANCE", value.AccessKeyID)
+}
+
+// Check if instance metadata is skipped when it is disabled, and fails fast when it does not answer
+func TestDefaultCredentialsInstanceMetadataUnavailable(t *testing.T) {
+	for _, test := range []struct {
+		name     string // Test name
+		disabled string // AWS_EC2_METADATA_DISABLED value
+		requests bool   // Instance metadata is requested
+	}{
+		{name: "disabled", disabled: "true", requests: false},
+		{name: "disabled upper case", disabled: "TRUE", requests: false},
+		{name: "not answering", disabled: "", requests: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			isolateCredentials(t)
+			t.Setenv("AWS_EC2_METADATA_DISABLED", test.disabled)
+
+			// Instance metadata stand-in answering too late
+			var requests atomic.Int32
+			release := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				select {
+				case <-release:
+				case <-time.After(5 * time.Second):
+				}
+			}))
+			defer server.Close()
+			defer close(release)
+
+			// Get credentials value from default chain, own http client has no timeout
+			creds, err := signerV4.NewDefaultCredentials(signerV4.WithIMDSEndpoint(server.URL),
+				signerV4.WithIMDSTimeout(50*time.Millisecond), signerV4.WithCredentialsHTTPClient(&http.Client{}))
+			assert.NoError(t, err)
+			start := time.Now()
+			_, err = creds.Get()
+
+			// ASSERTS
+			assert.Error(t, err)
+			assert.Less(t, time.Since(start), 3*time.Second)
+			assert.Equal(t, test.requests, requests.Load() > 0)
+		})
+	}
+}
+
+// Check if credentials come from web identity token file
+func TestDefaultCredentialsWebIdentity(t *testing.T) {
+	isolateCredentials(t)
+
+	// Web identity token file
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, os.WriteFile(tokenFile, []byte("web-identity-token"), 0600))
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", tokenFile)
+	t.Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/pod")
+	t.Setenv("AWS_ROLE_SESSION_NAME", "pod-session")
+
+	// STS stand-in
+	signedBy := []string{}
+	server := newSTS(t, &signedBy)
+	defer server.Close()
+
+	// Get credentials value from default chain
+	creds, err := signerV4.NewDefaultCredentials(signerV4.WithSTSEndpoint(server.URL))
+	assert.NoError(t, err)
+	value, err := creds.Get()
+
+	// ASSERTS
+	assert.NoError(t, err)
+	assert.Equal(t, "ASIApod", value.AccessKeyID)
+	assert.Equal(t, "token-pod", value.SessionToken)
+	assert.Empty(t, signedBy)
+}
+
+// Check if roles can be chained, every role is assumed with credentials of previous one
+func TestAssumeRoleChaining(t *testing.T) {
+	// STS stand-in
+	signedBy := []string{}
+	server := newSTS(t, &signedBy)
+	defer server.Close()
+
+	// Source credentials, first role and second role
+	source := credentials.NewStaticCredentials("AKIASOURCE", "secret", "")
+	first, err := signerV4.NewAssumeRoleCredentials(source, "arn:aws:iam::123456789012:role/first", signerV4.WithSTSEndpoint(server.URL))
+	assert.NoError(t, err)
+	second, err := signerV4.NewAssumeRoleCredentials(first, "arn:aws:iam::123456789012:role/second", signerV4.WithSTSEndpoint(server.URL))
+	assert.NoError(t, err)
+
+	// Signer with chained role
+	ownTestSigner, err := signerV4.New(signerV4.WithRegion(region), signerV4.WithService(service), signerV4.WithCredentials(second))
+	assert.NoError(t, err)
+	signedURL, err := ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, &date)
+
+	// ASSERTS
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"AKIASOURCE", "ASIAfirst"}, signedBy)
+	u, _ := url.Parse(signedURL)
+	assert.True(t, strings.HasPrefix(u.Query().Get("X-Amz-Credential"), "ASIAsecond/"))
+	assert.Equal(t, "token-second", u.Query().Get("X-Amz-Security-Token"))
+}
+
+// Check if signer uses input credentials provider
+func TestWithCredentialsProvider(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// New signer with provider
+	ownTestSigner, err := signerV4.New(signerV4.WithRegion(region), signerV4.WithService(service),
+		signerV4.WithCredentialsProvider(&credentials.StaticProvider{Value: credetialsValue}))
+	assert.NoError(t, err)
+	signedURL, err := ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, &date)
+
+	// ASSERTS
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSignedURL, signedURL)
+}
+
+// Check if default chain credentials expiring soon are refreshed in background
+func TestDefaultCredentialsRefreshBeforeExpiry(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+	isolateCredentials(t)
+
+	// Container credentials endpoint stand-in, first credentials expire soon
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accessKeyID, expires := "ASIAFIRST", time.Now().Add(7*time.Minute)
+		if atomic.AddInt32(&requests, 1) > 1 {
+			accessKeyID, expires = "ASIASECOND", time.Now().Add(time.Hour)
+		}
+		_, _ = w.Write([]byte(`{"AccessKeyId":"` + accessKeyID + `","SecretAccessKey":"secret","Token":"token","Expiration":"` +
+			expires.UTC().Format(time.RFC3339) + `"}`))
+	}))
+	defer server.Close()
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", server.URL+"/credentials")
+
+	// Signer with default chain
+	ownTestSigner, err := signerV4.New(signerV4.WithRegion(region), signerV4.WithService(service))
+	assert.NoError(t, err)
+
+	// if credentials rotated event
+	rotated := make(chan time.Time, 1)
+	ownTestSigner.OnCredentialsRotated(func(accessKeyID string, expires time.Time) {
+		assert.Equal(t, "ASIASECOND", accessKeyID)
+		rotated <- expires
+	})
+
+	// First signature starts refresh
+	first, err := ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, nil)
+	assert.NoError(t, err)
+	select {
+	case expires := <-rotated:
+		assert.WithinDuration(t, time.Now().Add(55*time.Minute), expires, time.Minute)
+	case <-time.After(time.Second):
+		t.Fatalf("Credentials were not refreshed before expiry")
+	}
+	second, err := ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, nil)
+
+	// ASSERTS
+	assert.NoError(t, err)
+	assert.Equal(t, "ASIAFIRST", signedAccessKey(t, first))
+	assert.Equal(t, "ASIASECOND", signedAccessKey(t, second))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
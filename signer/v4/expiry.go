@@ -0,0 +1,147 @@
+package v4
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// Default time before credentials expiry when they are refreshed in background
+const DefaultRefreshWindow = 5 * time.Minute
+
+// AWS error codes of expired credentials
+var expiredErrorCodes = map[string]bool{
+	"ExpiredToken":          true,
+	"ExpiredTokenException": true,
+	"RequestExpired":        true,
+}
+
+// Use own refresh window, credentials expiring within it are refreshed in background while
+// current ones are still used. 0 disables background refresh
+func WithRefreshWindow(window time.Duration) func(*Signer) {
+	return func(sc *Signer) {
+		sc.refreshWindow = window
+	}
+}
+
+// On Credentials Rotated Event Function, triggered when signer gets new credentials, like
+// refreshed session credentials. Expires is zero for credentials that do not expire
+func (s *Signer) OnCredentialsRotated(f func(accessKeyID string, expires time.Time)) {
+	s.credMu.Lock()
+	defer s.credMu.Unlock()
+	s.onCredentialsRotated = f
+}
+
+// ExpireCredentials forces next signature to retrieve credentials again, like after a request
+// rejected because of expired or rotated credentials
+func (s *Signer) ExpireCredentials() {
+	s.Credentials.Expire()
+}
+
+// Get credentials to sign. Expired credentials are retrieved again once, and credentials
+// expiring within refresh window are refreshed in background
+func (s *Signer) credentials() (credentials.Value, error) {
+	s.credMu.Lock()
+	// Background refresh in progress, last credentials are still valid
+	if s.refreshing && s.last.AccessKeyID != "" && !isExpired(s.lastExpires) {
+		cred := s.last
+		s.credMu.Unlock()
+		return cred, nil
+	}
+	s.credMu.Unlock()
+
+	cred, expires, err := s.retrieve()
+	// Retry once, provider may have newer credentials
+	if (err == nil && isExpired(expires)) || isExpiredError(err) {
+		s.Credentials.Expire()
+		cred, expires, err = s.retrieve()
+	}
+	if err != nil {
+		return cred, &signer.CredentialsError{Err: err}
+	}
+	if isExpired(expires) {
+		return cred, &signer.CredentialsError{Err: fmt.Errorf("credentials expired at %s", expires.UTC().Format(time.RFC3339))}
+	}
+
+	s.remember(cred, expires)
+	s.refreshAhead(expires)
+	return cred, nil
+}
+
+// Get credentials and their expiry, zero if provider does not tell it
+func (s *Signer) retrieve() (credentials.Value, time.Time, error) {
+	cred, err := s.Credentials.Get()
+	if err != nil {
+		return cred, time.Time{}, err
+	}
+
+	// Providers without expiry, like static ones, can not tell it
+	expires, err := s.Credentials.ExpiresAt()
+	var awsErr awserr.Error
+	if err != nil && !(errors.As(err, &awsErr) && awsErr.Code() == "ProviderNotExpirer") {
+		return cred, time.Time{}, err
+	}
+	return cred, expires, nil
+}
+
+// Start background refresh if credentials expire within refresh window
+func (s *Signer) refreshAhead(expires time.Time) {
+	if s.refreshWindow <= 0 || expires.IsZero() || time.Until(expires) > s.refreshWindow {
+		return
+	}
+
+	s.credMu.Lock()
+	defer s.credMu.Unlock()
+	// Already refreshing, or provider gave these credentials on last refresh
+	if s.refreshing || expires.Equal(s.refreshedExpires) {
+		return
+	}
+	s.refreshing = true
+	go s.refresh()
+}
+
+// Retrieve new credentials, signatures use current ones meanwhile
+func (s *Signer) refresh() {
+	s.Credentials.Expire()
+	cred, expires, err := s.retrieve()
+
+	s.credMu.Lock()
+	s.refreshing = false
+	s.refreshedExpires = expires
+	s.credMu.Unlock()
+
+	// Failed refresh is retried by next signature
+	if err == nil {
+		s.remember(cred, expires)
+	}
+}
+
+// Keep last credentials and trigger Credentials Rotated Event if they changed
+func (s *Signer) remember(cred credentials.Value, expires time.Time) {
+	s.credMu.Lock()
+	rotated := s.last.AccessKeyID != "" && (s.last.AccessKeyID != cred.AccessKeyID ||
+		s.last.SecretAccessKey != cred.SecretAccessKey || s.last.SessionToken != cred.SessionToken)
+	s.last = cred
+	s.lastExpires = expires
+	f := s.onCredentialsRotated
+	s.credMu.Unlock()
+
+	if rotated && f != nil {
+		f(cred.AccessKeyID, expires)
+	}
+}
+
+// Expiry checker, zero expiry never expires
+func isExpired(expires time.Time) bool {
+	return !expires.IsZero() && !time.Now().Before(expires)
+}
+
+// Expired credentials error checker
+func isExpiredError(err error) bool {
+	var awsErr awserr.Error
+	return errors.As(err, &awsErr) && expiredErrorCodes[awsErr.Code()]
+}
@@ -0,0 +1,167 @@
+package v4_test
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer"
+	signerV4 "github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer/v4"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/assert"
+)
+
+// Provider of session credentials, one per retrieve
+type sessionProvider struct {
+	credentials.Expiry
+	mu       sync.Mutex
+	sessions []credentials.Value // Credentials of every retrieve, last one is repeated
+	expires  []time.Time         // Expiry of every retrieve, last one is repeated
+	errs     []error             // Error of every retrieve, none when there is no error
+	release  chan struct{}       // Retrieves after first one wait for it, nil to not wait
+	retrieve int
+}
+
+// Retrieve next session credentials
+func (p *sessionProvider) Retrieve() (credentials.Value, error) {
+	p.mu.Lock()
+	i := p.retrieve
+	p.retrieve++
+	p.mu.Unlock()
+
+	if i > 0 && p.release != nil {
+		<-p.release
+	}
+	if i < len(p.errs) && p.errs[i] != nil {
+		return credentials.Value{}, p.errs[i]
+	}
+
+	value := p.sessions[last(i, len(p.sessions))]
+	p.SetExpiration(p.expires[last(i, len(p.expires))], 0)
+	return value, nil
+}
+
+// Index of a retrieve, last one is repeated
+func last(i int, n int) int {
+	if i >= n {
+		return n - 1
+	}
+	return i
+}
+
+// Retrieves done
+func (p *sessionProvider) retrieves() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.retrieve
+}
+
+// Session credentials with input access key
+func session(accessKeyID string) credentials.Value {
+	return credentials.Value{AccessKeyID: accessKeyID, SecretAccessKey: "secret-" + accessKeyID, SessionToken: "token-" + accessKeyID}
+}
+
+// Access key of a signed url
+func signedAccessKey(t *testing.T, signedURL string) string {
+	u, err := url.Parse(signedURL)
+	assert.NoError(t, err)
+	return strings.Split(u.Query().Get("X-Amz-Credential"), "/")[0]
+}
+
+// Check if credentials expiring within refresh window are refreshed in background
+func TestRefreshBeforeExpiry(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// First credentials expire soon, refreshed ones later
+	provider := &sessionProvider{
+		sessions: []credentials.Value{session("ASIAFIRST"), session("ASIASECOND")},
+		expires:  []time.Time{time.Now().Add(time.Minute), time.Now().Add(time.Hour)},
+		release:  make(chan struct{}),
+	}
+	ownTestSigner, _ := signerV4.New(signerV4.WithRegion(region), signerV4.WithService(service),
+		signerV4.WithCredentials(credentials.NewCredentials(provider)), signerV4.WithRefreshWindow(5*time.Minute))
+
+	// if credentials rotated event
+	rotated := make(chan string, 1)
+	ownTestSigner.OnCredentialsRotated(func(accessKeyID string, expires time.Time) {
+		rotated <- accessKeyID
+	})
+
+	// First signature starts refresh, next one does not wait for it
+	first, err := ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, nil)
+	assert.NoError(t, err)
+	second, err := ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, nil)
+	assert.NoError(t, err)
+
+	// Refresh finishes
+	close(provider.release)
+	select {
+	case accessKeyID := <-rotated:
+		assert.Equal(t, "ASIASECOND", accessKeyID)
+	case <-time.After(time.Second):
+		t.Fatalf("Credentials were not rotated")
+	}
+	third, err := ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, nil)
+
+	// ASSERTS
+	assert.NoError(t, err)
+	assert.Equal(t, "ASIAFIRST", signedAccessKey(t, first))
+	assert.Equal(t, "ASIAFIRST", signedAccessKey(t, second))
+	assert.Equal(t, "ASIASECOND", signedAccessKey(t, third))
+	assert.Equal(t, 2, provider.retrieves())
+}
+
+// Check if expired credentials are retrieved again once
+func TestExpiredCredentialsRetry(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Provider gives expired credentials first, then valid ones
+	expired := &sessionProvider{
+		sessions: []credentials.Value{session("ASIAEXPIRED"), session("ASIAVALID")},
+		expires:  []time.Time{time.Now().Add(-time.Minute), time.Now().Add(time.Hour)},
+	}
+	// Provider fails with expired token first
+	expiredToken := &sessionProvider{
+		sessions: []credentials.Value{session("ASIAVALID")},
+		expires:  []time.Time{time.Now().Add(time.Hour)},
+		errs:     []error{awserr.New("ExpiredToken", "token expired", nil)},
+	}
+
+	for _, provider := range []*sessionProvider{expired, expiredToken} {
+		ownTestSigner, _ := signerV4.New(signerV4.WithRegion(region), signerV4.WithService(service),
+			signerV4.WithCredentials(credentials.NewCredentials(provider)))
+		signedURL, err := ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, nil)
+
+		// ASSERTS
+		assert.NoError(t, err)
+		assert.Equal(t, "ASIAVALID", signedAccessKey(t, signedURL))
+		assert.Equal(t, 2, provider.retrieves())
+	}
+}
+
+// Check if credentials still expired after retry are a credentials error
+func TestExpiredCredentialsError(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Provider always gives expired credentials
+	provider := &sessionProvider{
+		sessions: []credentials.Value{session("ASIAEXPIRED")},
+		expires:  []time.Time{time.Now().Add(-time.Minute)},
+	}
+	ownTestSigner, _ := signerV4.New(signerV4.WithRegion(region), signerV4.WithService(service),
+		signerV4.WithCredentials(credentials.NewCredentials(provider)))
+	_, err := ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, nil)
+
+	// ASSERTS
+	assert.ErrorIs(t, err, signer.ErrCredentialsExpired)
+	var credentialsErr *signer.CredentialsError
+	assert.ErrorAs(t, err, &credentialsErr)
+	assert.Contains(t, credentialsErr.Unwrap().Error(), "credentials expired at")
+	assert.Equal(t, 2, provider.retrieves())
+}
@@ -0,0 +1,105 @@
+package v4
+
+import (
+	"context"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer"
+	awsV2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// Provider name of credentials retrieved by an SDK agnostic provider
+const agnosticProviderName = "SignerCredentialsProvider"
+
+// aws-sdk-go v1 credentials provider over an SDK agnostic one
+type agnosticProvider struct {
+	provider  signer.CredentialsProviderI // SDK agnostic provider
+	canExpire bool                        // Last retrieved credentials expire
+	expires   time.Time                   // When last retrieved credentials expire
+}
+
+// aws-sdk-go-v2 credentials provider as SDK agnostic one
+type awsV2Provider struct {
+	provider awsV2.CredentialsProvider // aws-sdk-go-v2 provider
+}
+
+// NewProvider returns an aws-sdk-go v1 credentials provider over an SDK agnostic one
+func NewProvider(provider signer.CredentialsProviderI) credentials.Provider {
+	return &agnosticProvider{provider: provider}
+}
+
+// NewAWSV2Provider returns an SDK agnostic credentials provider over an aws-sdk-go-v2 one,
+// like the Credentials of an aws.Config
+func NewAWSV2Provider(provider awsV2.CredentialsProvider) signer.CredentialsProviderI {
+	return &awsV2Provider{provider: provider}
+}
+
+// Add SDK agnostic credentials provider as option
+func WithProvider(provider signer.CredentialsProviderI) func(*Signer) {
+	return WithCredentialsProvider(NewProvider(provider))
+}
+
+// Add aws-sdk-go-v2 credentials provider as option
+func WithAWSV2CredentialsProvider(provider awsV2.CredentialsProvider) func(*Signer) {
+	return WithProvider(NewAWSV2Provider(provider))
+}
+
+// Add region and credentials of an aws-sdk-go-v2 config as option, config without
+// credentials keeps default credentials chain
+func WithAWSV2Config(config awsV2.Config) func(*Signer) {
+	return func(sc *Signer) {
+		if config.Region != "" {
+			sc.Region = config.Region
+		}
+		if config.Credentials != nil {
+			WithAWSV2CredentialsProvider(config.Credentials)(sc)
+		}
+	}
+}
+
+// Retrieve credentials from SDK agnostic provider and keep their expiry
+func (p *agnosticProvider) Retrieve() (credentials.Value, error) {
+	creds, err := p.provider.Retrieve(context.Background())
+	if err != nil {
+		return credentials.Value{ProviderName: agnosticProviderName}, err
+	}
+
+	p.canExpire = creds.CanExpire
+	p.expires = creds.Expires
+	return credentials.Value{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		ProviderName:    agnosticProviderName,
+	}, nil
+}
+
+// IsExpired returns true when last retrieved credentials expired
+func (p *agnosticProvider) IsExpired() bool {
+	return p.canExpire && !time.Now().Before(p.expires)
+}
+
+// ExpiresAt returns expiry of last retrieved credentials, zero time if they do not expire
+func (p *agnosticProvider) ExpiresAt() time.Time {
+	if !p.canExpire {
+		return time.Time{}
+	}
+	return p.expires
+}
+
+// Retrieve credentials from aws-sdk-go-v2 provider
+func (p *awsV2Provider) Retrieve(ctx context.Context) (signer.Credentials, error) {
+	creds, err := p.provider.Retrieve(ctx)
+	if err != nil {
+		return signer.Credentials{}, err
+	}
+
+	return signer.Credentials{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		CanExpire:       creds.CanExpire,
+		Expires:         creds.Expires,
+	}, nil
+}
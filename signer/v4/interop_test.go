@@ -0,0 +1,135 @@
+package v4_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer"
+	signerV4 "github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer/v4"
+	awsV2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+// SDK agnostic provider counting retrieves
+type countingProvider struct {
+	creds    signer.Credentials
+	retrieve int
+}
+
+// Retrieve input credentials, expired ones are renewed after first retrieve
+func (p *countingProvider) Retrieve(ctx context.Context) (signer.Credentials, error) {
+	p.retrieve++
+	if p.retrieve > 1 && p.creds.CanExpire {
+		p.creds.Expires = time.Now().Add(time.Hour)
+	}
+	return p.creds, nil
+}
+
+// aws-sdk-go-v2 provider with testing credentials
+func awsV2Credentials() awsV2.CredentialsProvider {
+	return awsV2.CredentialsProviderFunc(func(ctx context.Context) (awsV2.Credentials, error) {
+		return awsV2.Credentials{
+			AccessKeyID:     credetialsValue.AccessKeyID,
+			SecretAccessKey: credetialsValue.SecretAccessKey,
+			SessionToken:    credetialsValue.SessionToken,
+		}, nil
+	})
+}
+
+// Check if signer uses aws-sdk-go-v2 credentials provider
+func TestWithAWSV2CredentialsProvider(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// New signer with v2 provider
+	ownTestSigner, err := signerV4.New(signerV4.WithRegion(region), signerV4.WithService(service),
+		signerV4.WithAWSV2CredentialsProvider(awsV2Credentials()))
+	assert.NoError(t, err)
+	signedURL, err := ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, &date)
+
+	// ASSERTS
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSignedURL, signedURL)
+}
+
+// Check if signer uses region and credentials of aws-sdk-go-v2 config
+func TestWithAWSV2Config(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// New signer with v2 config only
+	ownTestSigner, err := signerV4.New(signerV4.WithService(service),
+		signerV4.WithAWSV2Config(awsV2.Config{Region: region, Credentials: awsV2Credentials()}))
+	assert.NoError(t, err)
+	signedURL, err := ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, &date)
+
+	// ASSERTS
+	assert.NoError(t, err)
+	assert.Equal(t, region, ownTestSigner.Region)
+	assert.Equal(t, expectedSignedURL, signedURL)
+}
+
+// Check if SDK agnostic credentials are retrieved again only when they expire
+func TestWithProviderExpiry(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+	creds := signer.Credentials{
+		AccessKeyID:     credetialsValue.AccessKeyID,
+		SecretAccessKey: credetialsValue.SecretAccessKey,
+		SessionToken:    credetialsValue.SessionToken,
+	}
+
+	// Long term credentials and expired ones
+	longTerm := &countingProvider{creds: creds}
+	creds.CanExpire = true
+	creds.Expires = time.Now().Add(-time.Minute)
+	expired := &countingProvider{creds: creds}
+
+	for _, provider := range []*countingProvider{longTerm, expired} {
+		ownTestSigner, err := signerV4.New(signerV4.WithRegion(region), signerV4.WithService(service), signerV4.WithProvider(provider))
+		assert.NoError(t, err)
+
+		// Signed twice
+		for i := 0; i < 2; i++ {
+			signedURL, err := ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, &date)
+			assert.NoError(t, err)
+			assert.Equal(t, expectedSignedURL, signedURL)
+		}
+	}
+
+	// ASSERTS
+	assert.Equal(t, 1, longTerm.retrieve)
+	assert.Equal(t, 2, expired.retrieve)
+}
+
+// Check if static SDK agnostic credentials are a provider and provider errors are credentials errors
+func TestWithProviderStaticAndError(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Static credentials
+	ownTestSigner, err := signerV4.New(signerV4.WithRegion(region), signerV4.WithService(service), signerV4.WithProvider(signer.Credentials{
+		AccessKeyID:     credetialsValue.AccessKeyID,
+		SecretAccessKey: credetialsValue.SecretAccessKey,
+		SessionToken:    credetialsValue.SessionToken,
+	}))
+	assert.NoError(t, err)
+	signedURL, err := ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, &date)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSignedURL, signedURL)
+
+	// Failing v2 provider
+	errProvider := errors.New("no credentials")
+	ownTestSigner, err = signerV4.New(signerV4.WithRegion(region), signerV4.WithService(service),
+		signerV4.WithAWSV2CredentialsProvider(awsV2.CredentialsProviderFunc(func(ctx context.Context) (awsV2.Credentials, error) {
+			return awsV2.Credentials{}, errProvider
+		})))
+	assert.NoError(t, err)
+	_, err = ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, &date)
+
+	// ASSERTS
+	assert.ErrorIs(t, err, signer.ErrCredentialsExpired)
+	assert.ErrorIs(t, err, errProvider)
+}
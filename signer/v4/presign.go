@@ -0,0 +1,129 @@
+package v4
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer"
+)
+
+// Default time a presigned url is valid
+const DefaultExpires = 299 * time.Second
+
+// Max time a presigned url can be valid
+const MaxExpires = 7 * 24 * time.Hour
+
+// Presign configure
+type PresignConfig struct {
+	Method  string            // Http method of request, GET when empty
+	Expires time.Duration     // How long url is valid
+	Headers map[string]string // Headers signed besides host, request must send them
+}
+
+// Sign url for input http method
+func WithMethod(method string) func(*PresignConfig) {
+	return func(c *PresignConfig) {
+		c.Method = method
+	}
+}
+
+// Use own time url is valid, from 1 second to 7 days
+func WithExpires(expires time.Duration) func(*PresignConfig) {
+	return func(c *PresignConfig) {
+		c.Expires = expires
+	}
+}
+
+// Sign input headers besides host, request must send them with same values
+func WithSignedHeaders(headers map[string]string) func(*PresignConfig) {
+	return func(c *PresignConfig) {
+		c.Headers = headers
+	}
+}
+
+// Presign function that signs input https or wss url, with its own query params and input query params,
+// at input date. Signature goes in query params
+func (s *Signer) Presign(endpoint string, queryParams signer.QueryParams, date *time.Time, options ...func(*PresignConfig)) (string, error) {
+	// Presign config with default values
+	config := &PresignConfig{Method: "GET", Expires: DefaultExpires}
+	for _, o := range options {
+		o(config)
+	}
+
+	// Check expiry
+	if config.Expires < time.Second || config.Expires > MaxExpires {
+		return "", signer.ErrInvalidExpires
+	}
+
+	// Validate and parse endpoint
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", &signer.EndpointError{Endpoint: endpoint, Reason: "is not a valid uri."}
+	}
+
+	// Check protocol
+	if u.Scheme != "https" && u.Scheme != "wss" {
+		return "", &signer.EndpointError{Endpoint: endpoint, Reason: "is not a secure endpoint. It should start with 'https://' or 'wss://'."}
+	}
+
+	// Get credentials to use
+	cred, err := s.credentials()
+	if err != nil {
+		return "", err
+	}
+
+	// if you don't give me the date, now is the date
+	if date == nil {
+		now := time.Now()
+		date = &now
+	}
+
+	// Prepare date strings
+	datetimeString := getDateTimeString(date)
+	dateString := getDateString(date)
+
+	// Default path if empty
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	// Prepare canonical headers, host and input headers
+	canonicalHeaders := signer.MergeMaps(config.Headers, map[string]string{"host": u.Host})
+	signedHeaders := signer.CreateSignedHeadersString(canonicalHeaders)
+	canonicalHeadersString := signer.CreateHeadersString(canonicalHeaders)
+
+	// Prepare canonical query params, url ones, input ones and signature ones
+	credentialScope := dateString + "/" + s.Region + "/" + s.Service + "/" + "aws4_request"
+	canonicalQueryParams := u.Query()
+	for k, v := range queryParams {
+		canonicalQueryParams.Set(k, v)
+	}
+	canonicalQueryParams.Set("X-Amz-Algorithm", DefaultAlgorithm)
+	canonicalQueryParams.Set("X-Amz-Credential", cred.AccessKeyID+"/"+credentialScope)
+	canonicalQueryParams.Set("X-Amz-Date", datetimeString)
+	canonicalQueryParams.Set("X-Amz-Expires", strconv.FormatInt(int64(config.Expires/time.Second), 10))
+	canonicalQueryParams.Set("X-Amz-SignedHeaders", signedHeaders)
+
+	// Add SessionToken as query params if it exists
+	if cred.SessionToken != "" {
+		canonicalQueryParams.Set("X-Amz-Security-Token", cred.SessionToken)
+	}
+
+	canonicalQueryString := signer.CreateCanonicalQueryString(canonicalQueryParams)
+
+	// Prepare payload hash
+	payloadHash := signer.SHA256("")
+
+	// Combine canonical request parts into a canonical request string and sign it
+	canonicalRequest := strings.Join([]string{config.Method, signer.EncodeURIPath(path), canonicalQueryString, canonicalHeadersString, signedHeaders, payloadHash}, "\n")
+	_, signature := s.sign(cred, dateString, datetimeString, canonicalRequest)
+
+	// Add signature to query params
+	canonicalQueryParams.Set("X-Amz-Signature", signature)
+
+	// Create signed URL
+	return u.Scheme + "://" + u.Host + path + "?" + signer.CreateCanonicalQueryString(canonicalQueryParams), nil
+}
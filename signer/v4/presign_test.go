@@ -0,0 +1,127 @@
+package v4_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer"
+	signerV4 "github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer/v4"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	awsV4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// Presign input url with AWS SDK signer, as reference
+func referencePresign(t *testing.T, method string, endpoint string, headers map[string]string, expires time.Duration) url.Values {
+	req, _ := http.NewRequest(method, endpoint, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	reference := awsV4.NewSigner(credentials.NewStaticCredentials(credetialsValue.AccessKeyID, credetialsValue.SecretAccessKey, credetialsValue.SessionToken))
+	_, err := reference.Presign(req, nil, service, region, expires, date)
+
+	// if something wrong happened
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return req.URL.Query()
+}
+
+// Query params of a signed url
+func signedQuery(t *testing.T, signedURL string) url.Values {
+	u, err := url.Parse(signedURL)
+
+	// if something wrong happened
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return u.Query()
+}
+
+// Check if https url with its own query params is signed like AWS SDK does
+func TestPresignHTTPSWithQueryParams(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Presign url
+	endpoint := "https://kvs.awsamazon.com/path?b=2&a=1&a=0"
+	signedURL, err := testSigner.(*signerV4.Signer).Presign(endpoint, nil, &date, signerV4.WithExpires(time.Hour))
+
+	// ASSERTS
+	assert.NoError(t, err)
+	query := signedQuery(t, signedURL)
+	assert.Equal(t, referencePresign(t, http.MethodGet, endpoint, nil, time.Hour), query)
+	assert.Equal(t, []string{"0", "1"}, query["a"])
+	assert.Equal(t, "3600", query.Get("X-Amz-Expires"))
+}
+
+// Check if input query params are merged with url ones
+func TestPresignMergeQueryParams(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Presign url
+	signedURL, err := testSigner.(*signerV4.Signer).Presign("wss://kvs.awsamazon.com?b=2", queryParams, &date)
+
+	// ASSERTS
+	assert.NoError(t, err)
+	query := signedQuery(t, signedURL)
+	assert.Equal(t, referencePresign(t, http.MethodGet, "wss://kvs.awsamazon.com?b=2&X-Amz-TestParam=test-param-value", nil, signerV4.DefaultExpires), query)
+	assert.Equal(t, "2", query.Get("b"))
+	assert.Equal(t, "test-param-value", query.Get("X-Amz-TestParam"))
+}
+
+// Check if additional headers are signed
+func TestPresignSignedHeaders(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Presign url
+	headers := map[string]string{"Content-Type": "application/json", "X-Amz-Meta-Test": "  a   b "}
+	signedURL, err := testSigner.(*signerV4.Signer).Presign("https://kvs.awsamazon.com/upload", nil, &date,
+		signerV4.WithMethod(http.MethodPut), signerV4.WithSignedHeaders(headers))
+
+	// ASSERTS
+	assert.NoError(t, err)
+	query := signedQuery(t, signedURL)
+	assert.Equal(t, "content-type;host;x-amz-meta-test", query.Get("X-Amz-SignedHeaders"))
+	assert.Equal(t, referencePresign(t, http.MethodPut, "https://kvs.awsamazon.com/upload", headers, signerV4.DefaultExpires), query)
+}
+
+// Check if expiry is validated
+func TestPresignInvalidExpires(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	for _, expires := range []time.Duration{0, 8 * 24 * time.Hour} {
+		_, err := testSigner.(*signerV4.Signer).Presign("https://kvs.awsamazon.com", nil, &date, signerV4.WithExpires(expires))
+		assert.ErrorIs(t, err, signer.ErrInvalidExpires)
+	}
+}
+
+// Check if only secure endpoints are signed
+func TestPresignInvalidEndpoint(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	_, err := testSigner.(*signerV4.Signer).Presign("http://kvs.awsamazon.com", nil, &date)
+	assert.ErrorIs(t, err, signer.ErrInvalidEndpoint)
+}
+
+// Check if paths and query params that need encoding are signed like AWS SDK does
+func TestPresignEncoding(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Presign url
+	endpoint := "https://kvs.awsamazon.com/a%20b/c+d?q=x%20y&r=1*2&r=~"
+	signedURL, err := testSigner.(*signerV4.Signer).Presign(endpoint, nil, &date)
+
+	// ASSERTS
+	assert.NoError(t, err)
+	assert.Contains(t, signedURL, "https://kvs.awsamazon.com/a%20b/c+d?")
+	assert.Contains(t, signedURL, "&q=x%20y&r=1%2A2&r=~")
+	assert.Equal(t, referencePresign(t, http.MethodGet, endpoint, nil, signerV4.DefaultExpires), signedQuery(t, signedURL))
+}
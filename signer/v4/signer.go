@@ -2,9 +2,11 @@ package v4
 
 import (
 	"encoding/hex"
-	"errors"
+	"io"
+	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer"
@@ -14,22 +16,71 @@ import (
 const (
 	// DefaultAlgorithm used for AWS V4 Signed
 	DefaultAlgorithm = "AWS4-HMAC-SHA256"
+	// UnsignedPayload as X-Amz-Content-Sha256 header value signs a request without hashing its body
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
 )
 
+// Header with payload hash
+const contentSHA256Header = "X-Amz-Content-Sha256"
+
+// Headers never signed, they are changed on the way or they are the signature
+var ignoredHeaders = map[string]bool{
+	"authorization":   true,
+	"user-agent":      true,
+	"x-amzn-trace-id": true,
+	"expect":          true,
+}
+
 // AWS V4 Signer
 type Signer struct {
-	Region      string
-	Credentials *credentials.Credentials
-	Service     string
+	Region               string
+	Credentials          *credentials.Credentials
+	Service              string
+	keyMu                sync.Mutex                                  // Protect cached signing key
+	key                  *signingKey                                 // Last derived signing key
+	credMu               sync.Mutex                                  // Protect last credentials, refresh state and event function
+	last                 credentials.Value                           // Last credentials used to sign
+	lastExpires          time.Time                                   // When last credentials expire, zero if they do not expire
+	refreshWindow        time.Duration                               // Refresh credentials in background when they expire within it
+	refreshing           bool                                        // Background refresh in progress
+	refreshedExpires     time.Time                                   // Expiry of credentials got by last background refresh
+	onCredentialsRotated func(accessKeyID string, expires time.Time) // Function for Credentials Rotated Event
 }
 
-// Get Signature from datestring, region and service using credentials
-func (s *Signer) getSignatureKey(dateString string) []byte {
-	cred, _ := s.Credentials.Get()
+// Signing key derived for a day, region and service from credentials
+type signingKey struct {
+	accessKeyID     string // Credentials access key
+	secretAccessKey string // Credentials secret, rotated credentials may keep access key
+	date            string // Day of signature
+	region          string // AWS Region
+	service         string // AWS Service
+	key             []byte // Derived signing key
+}
+
+// Get Signature key from datestring, region and service using input credentials. It is derived
+// once per day, region, service and credentials
+func (s *Signer) getSignatureKey(cred credentials.Value, dateString string) []byte {
+	s.keyMu.Lock()
+	defer s.keyMu.Unlock()
+
+	// Cached key is still valid
+	if k := s.key; k != nil && k.accessKeyID == cred.AccessKeyID && k.secretAccessKey == cred.SecretAccessKey &&
+		k.date == dateString && k.region == s.Region && k.service == s.Service {
+		return k.key
+	}
+
 	date := signer.HMAC([]byte("AWS4"+cred.SecretAccessKey), dateString)
 	region := signer.HMAC(date, s.Region)
 	service := signer.HMAC(region, s.Service)
-	return signer.HMAC(service, "aws4_request")
+	s.key = &signingKey{
+		accessKeyID:     cred.AccessKeyID,
+		secretAccessKey: cred.SecretAccessKey,
+		date:            dateString,
+		region:          s.Region,
+		service:         s.Service,
+		key:             signer.HMAC(service, "aws4_request"),
+	}
+	return s.key.key
 }
 
 // Add credentials value as option
@@ -62,8 +113,8 @@ func WithService(service string) func(*Signer) {
 // New Signer
 func New(options ...func(*Signer)) (*Signer, error) {
 
-	// Signed empty
-	rs := &Signer{}
+	// Signer with default values
+	rs := &Signer{refreshWindow: DefaultRefreshWindow}
 
 	// Add With Options
 	for _, o := range options {
@@ -72,44 +123,28 @@ func New(options ...func(*Signer)) (*Signer, error) {
 
 	// Check if it added credentials
 	if rs.Credentials == nil {
-		// Create Chain in order
-		// 1.Env Provider
-		// 2.Shared Credentials Provider
-		rs.Credentials = credentials.NewChainCredentials(
-			[]credentials.Provider{
-				&credentials.EnvProvider{},
-				&credentials.SharedCredentialsProvider{},
-			})
+		// Create default chain, STS requests go to signer region
+		options := []func(*CredentialsConfig){}
+		if rs.Region != "" {
+			options = append(options, WithSTSRegion(rs.Region))
+		}
+		creds, err := NewDefaultCredentials(options...)
+		if err != nil {
+			return nil, err
+		}
+		rs.Credentials = creds
 	}
 
 	// Return signer
 	return rs, nil
 }
 
-// GetSignedURL function that sign input url, input query params and input date
+// GetSignedURL function that sign input wss url, input query params and input date. Url must not have query params
 func (s *Signer) GetSignedURL(endpoint string, queryParams signer.QueryParams, date *time.Time) (string, error) {
-	// Get credentials to use
-	cred, err := s.Credentials.Get()
-	if err != nil {
-		return "", errors.New("credentials for sign invalid because they are non-existent or expired")
-	}
-
-	// Get date now
-	var now = time.Now()
-
-	// if you don't give me the date, now is the date
-	if date == nil {
-		date = &now
-	}
-
-	// Prepare date strings
-	datetimeString := getDateTimeString(date)
-	dateString := getDateString(date)
-
 	// Validate and parse endpoint
 	u, err := url.Parse(endpoint)
 	if err != nil {
-		return "", errors.New("Endpoint '" + endpoint + "' is not a valid uri.")
+		return "", &signer.EndpointError{Endpoint: endpoint, Reason: "is not a valid uri."}
 	}
 
 	var protocol = "wss"
@@ -117,71 +152,116 @@ func (s *Signer) GetSignedURL(endpoint string, queryParams signer.QueryParams, d
 
 	// Check protocol
 	if u.Scheme != protocol {
-		return "", errors.New("Endpoint '" + endpoint + "' is not a secure WebSocket endpoint. It should start with '" + urlProtocol + "'.")
+		return "", &signer.EndpointError{Endpoint: endpoint, Reason: "is not a secure WebSocket endpoint. It should start with '" + urlProtocol + "'."}
 	}
 
 	// Check if it contains params
 	if strings.Contains(endpoint, "?") {
-		return "", errors.New("Endpoint '" + endpoint + "' should not contain any query parameters")
+		return "", &signer.EndpointError{Endpoint: endpoint, Reason: "should not contain any query parameters"}
 	}
 
-	// Get host and path from url
-	host := u.Host
-	path := u.Path
+	return s.Presign(endpoint, queryParams, date)
+}
 
-	// Default path if nil
-	if path == "" {
-		path = "/"
+// SignRequest function that signs input http request at input date with an Authorization header.
+// Every header is signed but ignored ones, body is hashed as payload and rewound. Set X-Amz-Content-Sha256
+// header to UnsignedPayload, or to a precomputed hash, and body is not read
+func (s *Signer) SignRequest(req *http.Request, body io.ReadSeeker, date *time.Time) error {
+	// Get credentials to use
+	cred, err := s.credentials()
+	if err != nil {
+		return err
 	}
 
-	// Add default host header
-	signedHeaders := strings.Join([]string{"host"}, ";")
-
-	// Prepare method
-	method := "GET" // Method is always GET for signed URLs
-
-	// Prepare canonical query string
-	credentialScope := dateString + "/" + s.Region + "/" + s.Service + "/" + "aws4_request"
+	// if you don't give me the date, now is the date
+	if date == nil {
+		now := time.Now()
+		date = &now
+	}
 
-	canonicalQueryParams := signer.MergeMaps(queryParams, map[string]string{
-		"X-Amz-Algorithm":     DefaultAlgorithm,
-		"X-Amz-Credential":    cred.AccessKeyID + "/" + credentialScope,
-		"X-Amz-Date":          datetimeString,
-		"X-Amz-Expires":       "299",
-		"X-Amz-SignedHeaders": signedHeaders,
-	})
+	// Prepare date strings
+	datetimeString := getDateTimeString(date)
+	dateString := getDateString(date)
 
-	// Add SessionToken as query params if it exists
+	// Date and session token are signed headers too
+	req.Header.Set("X-Amz-Date", datetimeString)
 	if cred.SessionToken != "" {
-		canonicalQueryParams["X-Amz-Security-Token"] = cred.SessionToken
+		req.Header.Set("X-Amz-Security-Token", cred.SessionToken)
 	}
 
-	// Query params to query param string
-	canonicalQueryString := signer.CreateQueryString(canonicalQueryParams)
+	// Prepare payload hash, unless caller did it
+	payloadHash := req.Header.Get(contentSHA256Header)
+	if payloadHash == "" {
+		if payloadHash, err = hashPayload(body); err != nil {
+			return err
+		}
+	}
 
 	// Prepare canonical headers
-	canonicalHeaders := map[string]string{"host": host}
+	canonicalHeaders := map[string]string{"host": requestHost(req)}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if ignoredHeaders[name] {
+			continue
+		}
+		canonicalHeaders[name] = signer.CanonicalHeaderValue(values)
+	}
+	signedHeaders := signer.CreateSignedHeadersString(canonicalHeaders)
 	canonicalHeadersString := signer.CreateHeadersString(canonicalHeaders)
 
-	// Prepare payload hash
-	payloadHash := signer.SHA256("")
+	// Path as sent is encoded again, default path if empty
+	path := signer.EncodeURIPath(req.URL.EscapedPath())
 
 	// Combine canonical request parts into a canonical request string and hash
-	canonicalRequest := strings.Join([]string{method, path, canonicalQueryString, canonicalHeadersString, signedHeaders, payloadHash}, "\n")
-	canonicalRequestHash := signer.SHA256(canonicalRequest)
+	canonicalQueryString := signer.CreateCanonicalQueryString(req.URL.Query())
+	canonicalRequest := strings.Join([]string{req.Method, path, canonicalQueryString, canonicalHeadersString, signedHeaders, payloadHash}, "\n")
+	credentialScope, signature := s.sign(cred, dateString, datetimeString, canonicalRequest)
+
+	// Add Authorization header
+	req.Header.Set("Authorization", DefaultAlgorithm+" Credential="+cred.AccessKeyID+"/"+credentialScope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+	return nil
+}
 
-	// Create signature
-	stringToSign := strings.Join([]string{DefaultAlgorithm, datetimeString, credentialScope, canonicalRequestHash}, "\n")
-	signingKey := s.getSignatureKey(dateString)
-	signature := signer.HMAC(signingKey, stringToSign)
+// Sign canonical request with credentials used to build it, it returns credential scope and hex signature
+func (s *Signer) sign(cred credentials.Value, dateString string, datetimeString string, canonicalRequest string) (string, string) {
+	credentialScope := dateString + "/" + s.Region + "/" + s.Service + "/" + "aws4_request"
+	stringToSign := strings.Join([]string{DefaultAlgorithm, datetimeString, credentialScope, signer.SHA256(canonicalRequest)}, "\n")
+	signingKey := s.getSignatureKey(cred, dateString)
+	return credentialScope, hex.EncodeToString(signer.HMAC(signingKey, stringToSign))
+}
 
-	// Add signature to query params
-	signedQueryParams := signer.MergeMaps(canonicalQueryParams, map[string]string{
-		"X-Amz-Signature": hex.EncodeToString(signature),
-	})
+// Host of a request without default port, request host wins over url host
+func requestHost(req *http.Request) string {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
 
-	// Create signed URL
-	return protocol + "://" + host + path + "?" + signer.CreateQueryString(signedQueryParams), nil
+	// Default ports are not part of signed host
+	switch {
+	case req.URL.Scheme == "https" && strings.HasSuffix(host, ":443"):
+		host = strings.TrimSuffix(host, ":443")
+	case req.URL.Scheme == "http" && strings.HasSuffix(host, ":80"):
+		host = strings.TrimSuffix(host, ":80")
+	}
+	return host
+}
+
+// Hash payload and rewind it, nil body is an empty payload
+func hashPayload(body io.ReadSeeker) (string, error) {
+	if body == nil {
+		return signer.SHA256(""), nil
+	}
+
+	payload, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	if _, err = body.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return signer.SHA256(string(payload)), nil
 }
 
 // Get datetime as a valid string AWS V4 signature date format
@@ -1,13 +1,17 @@
 package v4_test
 
 import (
+	"io"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer"
 	signerV4 "github.com/BeHumans/amazon-kinesis-video-streams-webrtc-sdk-go/signer/v4"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	awsV4 "github.com/aws/aws-sdk-go/aws/signer/v4"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -88,6 +92,7 @@ func TestWithSharedCredentials(t *testing.T) {
 	os.Unsetenv("AWS_ACCESS_KEY")
 	os.Unsetenv("AWS_SECRET_ACCESS_KEY")
 	os.Unsetenv("AWS_SECRET_KEY")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
 
 	// New signer without input credentials
 	ownTestSigner, err := signerV4.New(
@@ -254,3 +259,289 @@ func TestValidSignedWithoutMockedDate(t *testing.T) {
 	}
 
 }
+
+// Check if endpoint errors can be matched
+func TestInvalidEndpointIsInvalidEndpointError(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Check if error exists calling Get signed URL
+	_, err := testSigner.GetSignedURL("https://kvs.awsamazon.com", queryParams, &date)
+
+	// ASSERTS
+	assert.ErrorIs(t, err, signer.ErrInvalidEndpoint)
+	var endpointErr *signer.EndpointError
+	assert.ErrorAs(t, err, &endpointErr)
+	assert.Equal(t, "https://kvs.awsamazon.com", endpointErr.Endpoint)
+}
+
+// Check if credentials errors can be matched
+func TestCredentialsExpiredError(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Nothing in env and shared credentials file does not exist
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_ACCESS_KEY", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_SECRET_KEY", "")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent/credentials")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	// New signer without input credentials
+	ownTestSigner, err := signerV4.New(
+		signerV4.WithRegion(region),
+		signerV4.WithService(service))
+
+	// if err something wrong
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Check if error exists calling Get signed URL
+	_, err = ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, &date)
+
+	// ASSERTS
+	expectedErrorMsg := "credentials for sign invalid because they are non-existent or expired"
+	assert.EqualErrorf(t, err, expectedErrorMsg, "Error should be: %v, got: %v", expectedErrorMsg, err)
+	assert.ErrorIs(t, err, signer.ErrCredentialsExpired)
+	var credentialsErr *signer.CredentialsError
+	assert.ErrorAs(t, err, &credentialsErr)
+	assert.Error(t, credentialsErr.Unwrap())
+}
+
+// New signer with credentials of AWS Signature V4 test suite
+func newTestSuiteSigner(sessionToken string) *signerV4.Signer {
+	ownTestSigner, _ := signerV4.New(
+		signerV4.WithRegion("us-east-1"),
+		signerV4.WithService("service"),
+		signerV4.WithCredentialsValue(&credentials.Value{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+			SessionToken:    sessionToken,
+		}))
+	return ownTestSigner
+}
+
+// Check if requests are signed like AWS Signature V4 test suite
+func TestSignRequestTestSuite(t *testing.T) {
+	// Test suite date
+	suiteDate, _ := time.Parse("20060102T150405Z", "20150830T123600Z")
+	stsToken := "AQoDYXdzEPT//////////wEXAMPLEtc764bNrC9SAPBSM22wDOk4x4HIZ8j4FZTwdQWLWsKWHGBuFqwAeMicRXmxfpSPfIeoIYRqTflfKD8YUuwthAx7mSEI/" +
+		"qkPpKPi/kMcGdQrmGdeehM4IC1NtBmUpp2wUE8phUZampKsburEDy0KPkyQDYwT7WZ0wq5VSXDvp75YU9HFvlRd8Tx6q6fE8YQcHNVXAkiY9q6d+xo0rKwT38xVqr7ZD0u0i" +
+		"PPkUL64lIZbqBAz+scqKmlzm8FDrypNC9Yjc8fPOLn9FX9KSYvKTr4rvx3iSIlTJabIQwj2ICCR/oLxBA=="
+
+	// Test suite cases
+	tests := []struct {
+		name          string
+		method        string
+		url           string
+		headers       [][2]string
+		body          string
+		sessionToken  string
+		signedHeaders string
+		signature     string
+	}{
+		{"get-vanilla", "GET", "https://example.amazonaws.com/", nil, "", "",
+			"host;x-amz-date", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"post-vanilla", "POST", "https://example.amazonaws.com/", nil, "", "",
+			"host;x-amz-date", "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b"},
+		{"get-vanilla-query-order-key-case", "GET", "https://example.amazonaws.com/?Param2=value2&Param1=value1", nil, "", "",
+			"host;x-amz-date", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+		{"get-header-value-trim", "GET", "https://example.amazonaws.com/", [][2]string{{"My-Header1", " value1"}, {"My-Header2", "\"a   b   c\""}}, "", "",
+			"host;my-header1;my-header2;x-amz-date", "acc3ed3afb60bb290fc8d2dd0098b9911fcaa05412b367055dee359757a9c736"},
+		{"get-header-key-duplicate", "GET", "https://example.amazonaws.com/", [][2]string{{"My-Header1", "value2"}, {"My-Header1", "value2"}, {"My-Header1", "value1"}}, "", "",
+			"host;my-header1;x-amz-date", "c9d5ea9f3f72853aea855b47ea873832890dbdd183b4468f858259531a5138ea"},
+		{"post-header-value-case", "POST", "https://example.amazonaws.com/", [][2]string{{"My-Header1", "VALUE1"}}, "", "",
+			"host;my-header1;x-amz-date", "cdbc9802e29d2942e5e10b5bccfdd67c5f22c7c4e8ae67b53629efa58b974b7d"},
+		{"post-x-www-form-urlencoded", "POST", "https://example.amazonaws.com/", [][2]string{{"Content-Type", "application/x-www-form-urlencoded"}}, "Param1=value1", "",
+			"content-type;host;x-amz-date", "ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a"},
+		{"post-sts-header-before", "POST", "https://example.amazonaws.com/", nil, "", stsToken,
+			"host;x-amz-date;x-amz-security-token", "85d96828115b5dc0cfc3bd16ad9e210dd772bbebba041836c64533a82be05ead"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Sign request
+			body := strings.NewReader(test.body)
+			req, _ := http.NewRequest(test.method, test.url, body)
+			for _, header := range test.headers {
+				req.Header.Add(header[0], header[1])
+			}
+			err := newTestSuiteSigner(test.sessionToken).SignRequest(req, body, &suiteDate)
+
+			// ASSERTS
+			assert.NoError(t, err)
+			assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+			assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+				"SignedHeaders="+test.signedHeaders+", Signature="+test.signature, req.Header.Get("Authorization"))
+		})
+	}
+}
+
+// Check if unsigned payload is not read and ignored headers are not signed
+func TestSignRequestUnsignedPayload(t *testing.T) {
+	// Test suite date
+	suiteDate, _ := time.Parse("20060102T150405Z", "20150830T123600Z")
+
+	// Request with a body that can not be read
+	req, _ := http.NewRequest(http.MethodPut, "https://example.amazonaws.com:443/", nil)
+	req.Header.Set("X-Amz-Content-Sha256", signerV4.UnsignedPayload)
+	req.Header.Set("User-Agent", "test")
+	req.Header.Set("Authorization", "old signature")
+	err := newTestSuiteSigner("").SignRequest(req, nil, &suiteDate)
+
+	// ASSERTS
+	assert.NoError(t, err)
+	assert.Contains(t, req.Header.Get("Authorization"), "SignedHeaders=host;x-amz-content-sha256;x-amz-date, ")
+}
+
+// Check if request payload is signed and body can be read again
+func TestSignRequestWithBody(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Sign request with json body
+	body := strings.NewReader(`{"ChannelName":"test"}`)
+	req, _ := http.NewRequest(http.MethodPost, "https://kinesisvideo.us-west-2.amazonaws.com/describeSignalingChannel", body)
+	req.Header.Set("Content-Type", "application/json")
+	err := testSigner.(signer.RequestSignerI).SignRequest(req, body, &date)
+
+	// if err something wrong
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// ASSERTS
+	assert.Equal(t, "FakeSessionToken", req.Header.Get("X-Amz-Security-Token"))
+	assert.Contains(t, req.Header.Get("Authorization"), "SignedHeaders=content-type;host;x-amz-date;x-amz-security-token, ")
+	payload, _ := io.ReadAll(body)
+	assert.Equal(t, `{"ChannelName":"test"}`, string(payload))
+}
+
+// Check if requests with paths and query params that need encoding are signed like AWS SDK does
+func TestSignRequestEncoding(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// Same request signed by both signers
+	newRequest := func() *http.Request {
+		req, _ := http.NewRequest(http.MethodGet, "https://kvs.awsamazon.com/a%20b/c+d?q=x%20y&q=a&r=1*2", nil)
+		req.Header.Set("My-Header", "  a   b ")
+		return req
+	}
+	req := newRequest()
+	err := testSigner.(signer.RequestSignerI).SignRequest(req, nil, &date)
+	reference := newRequest()
+	_, referenceErr := awsV4.NewSigner(credentials.NewStaticCredentials(credetialsValue.AccessKeyID, credetialsValue.SecretAccessKey,
+		credetialsValue.SessionToken)).Sign(reference, nil, service, region, date)
+
+	// ASSERTS
+	assert.NoError(t, err)
+	assert.NoError(t, referenceErr)
+	assert.Equal(t, reference.Header.Get("Authorization"), req.Header.Get("Authorization"))
+}
+
+// Credentials provider returning current value, rotated values are expired ones
+type rotatingProvider struct {
+	value   credentials.Value
+	rotated bool
+}
+
+// Retrieve current value
+func (p *rotatingProvider) Retrieve() (credentials.Value, error) {
+	p.rotated = false
+	return p.value, nil
+}
+
+// Expired after rotation
+func (p *rotatingProvider) IsExpired() bool {
+	return p.rotated
+}
+
+// Check if cached signing key is not used after credentials rotation
+func TestSigningKeyRotation(t *testing.T) {
+	// Load Initial values
+	InitInfo()
+
+	// New signer with rotating credentials
+	provider := &rotatingProvider{value: credetialsValue}
+	ownTestSigner, _ := signerV4.New(signerV4.WithRegion(region), signerV4.WithService(service))
+	ownTestSigner.Credentials = credentials.NewCredentials(provider)
+
+	// Signed twice with same credentials
+	first, err := ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, &date)
+	assert.NoError(t, err)
+	second, err := ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, &date)
+	assert.NoError(t, err)
+
+	// Rotate secret, access key is the same
+	rotatedValue := credetialsValue
+	rotatedValue.SecretAccessKey = "RotatedSecretKey"
+	provider.value = rotatedValue
+	provider.rotated = true
+	rotated, err := ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, &date)
+	assert.NoError(t, err)
+
+	// Signer that never had old credentials
+	freshSigner, _ := signerV4.New(signerV4.WithRegion(region), signerV4.WithService(service), signerV4.WithCredentialsValue(&rotatedValue))
+	expected, err := freshSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, &date)
+	assert.NoError(t, err)
+
+	// ASSERTS
+	assert.Equal(t, expectedSignedURL, first)
+	assert.Equal(t, first, second)
+	assert.Equal(t, expected, rotated)
+	assert.NotEqual(t, first, rotated)
+
+	// Next day signing key
+	nextDay := date.Add(24 * time.Hour)
+	nextDayURL, err := ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, &nextDay)
+	assert.NoError(t, err)
+	expected, err = freshSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, &nextDay)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, nextDayURL)
+}
+
+// Benchmark signed url with cached signing key
+func BenchmarkGetSignedURL(b *testing.B) {
+	// Load Initial values
+	InitInfo()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := testSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, &date); err != nil {
+			b.Fatalf("Unexpected error: %v", err)
+		}
+	}
+}
+
+// Benchmark signed url with a new signing key every time
+func BenchmarkGetSignedURLNewSigner(b *testing.B) {
+	// Load Initial values
+	InitInfo()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ownTestSigner, _ := signerV4.New(signerV4.WithRegion(region), signerV4.WithService(service), signerV4.WithCredentialsValue(&credetialsValue))
+		if _, err := ownTestSigner.GetSignedURL("wss://kvs.awsamazon.com", queryParams, &date); err != nil {
+			b.Fatalf("Unexpected error: %v", err)
+		}
+	}
+}
+
+// Benchmark signed request with json body
+func BenchmarkSignRequest(b *testing.B) {
+	// Load Initial values
+	InitInfo()
+
+	body := strings.NewReader(`{"ChannelARN":"arn:aws:kinesisvideo:us-west-2:123456789012:channel/testChannel/1234567890"}`)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		req, _ := http.NewRequest(http.MethodPost, "https://kinesisvideo.us-west-2.amazonaws.com/describeSignalingChannel", body)
+		req.Header.Set("Content-Type", "application/json")
+		if err := testSigner.(signer.RequestSignerI).SignRequest(req, body, &date); err != nil {
+			b.Fatalf("Unexpected error: %v", err)
+		}
+	}
+}